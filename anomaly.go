@@ -0,0 +1,78 @@
+package lru
+
+import "time"
+
+// AnomalyKind is the kind of an Anomaly.
+type AnomalyKind int
+
+const (
+	// MissSpike means the fraction of lookups that missed jumped.
+	MissSpike AnomalyKind = iota
+	// EvictionCliff means the number of entries evicted to make room jumped.
+	EvictionCliff
+)
+
+func (k AnomalyKind) String() string {
+	switch k {
+	case MissSpike:
+		return "miss spike"
+	case EvictionCliff:
+		return "eviction cliff"
+	default:
+		return "unknown"
+	}
+}
+
+// Anomaly is a sudden change in a cache's access pattern, reported to the callback set by
+// WithAnomalyDetector.
+type Anomaly struct {
+	Kind AnomalyKind
+	// Previous and Current are the metric over the interval before and the interval that just
+	// ended: the miss ratio for MissSpike, the number of evictions for EvictionCliff.
+	Previous, Current float64
+}
+
+// detect compares the cache's stats every interval with those of the previous interval, calling
+// fn for each metric that grew by factor or more, until the cache is closed.
+func (c *Cache[K, V]) detect(interval time.Duration, factor float64, fn func(Anomaly)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	last := c.Stats()
+	var prev Stats
+	havePrev := false // whether prev covers a whole interval
+	for {
+		select {
+		case <-t.C:
+			s := c.Stats()
+			if s.Hits < last.Hits || s.Misses < last.Misses || s.Evictions < last.Evictions {
+				// reset by ResetStats, start over.
+				last, havePrev = s, false
+				continue
+			}
+			cur := s.Delta(last)
+			if havePrev {
+				for _, a := range anomalies(prev, cur, factor) {
+					fn(a)
+				}
+			}
+			last, prev, havePrev = s, cur, true
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// anomalies returns the anomalies between the stats of two consecutive intervals, prev and cur.
+func anomalies(prev, cur Stats, factor float64) []Anomaly {
+	var found []Anomaly
+	if cur.Hits+cur.Misses > 0 && prev.Hits+prev.Misses > 0 {
+		p, c := 1-prev.HitRatio(), 1-cur.HitRatio()
+		if c > 0 && c >= factor*p {
+			found = append(found, Anomaly{MissSpike, p, c})
+		}
+	}
+	if p, c := float64(prev.Evictions), float64(cur.Evictions); c > 0 && c >= factor*p {
+		found = append(found, Anomaly{EvictionCliff, p, c})
+	}
+	return found
+}
@@ -0,0 +1,55 @@
+package lru
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAnomalies(t *testing.T) {
+	steady := Stats{Hits: 90, Misses: 10, Evictions: 5}
+	if got := anomalies(steady, steady, 2); len(got) != 0 {
+		t.Fatalf("steady traffic reported %v", got)
+	}
+	spike := Stats{Hits: 50, Misses: 50, Evictions: 20}
+	got := anomalies(steady, spike, 2)
+	if len(got) != 2 || got[0].Kind != MissSpike || got[0].Current != 0.5 || got[1] != (Anomaly{EvictionCliff, 5, 20}) {
+		t.Fatalf("anomalies %v, want a miss spike to 0.5 and an eviction cliff from 5 to 20", got)
+	}
+	if got := anomalies(Stats{}, Stats{Misses: 1}, 2); len(got) != 0 {
+		t.Fatalf("an interval without lookups reported %v", got)
+	}
+}
+
+func TestAnomalyDetector(t *testing.T) {
+	found := make(chan Anomaly, 10)
+	c := New[int](1, time.Hour, func(i int) {}, WithAnomalyDetector(20*time.Millisecond, 2, func(a Anomaly) { found <- a }))
+	defer c.Close()
+	c.Put(0, 0)
+	deadline := time.After(time.Second)
+	for i := 1; ; i++ {
+		// hits first, then only misses and evictions.
+		if i < 5 {
+			c.Get(0)
+		} else {
+			c.Get(-i)
+			c.Put(i, i)
+		}
+		select {
+		case a := <-found:
+			if a.Kind != MissSpike && a.Kind != EvictionCliff {
+				t.Fatalf("unexpected anomaly %+v", a)
+			}
+			return
+		case <-deadline:
+			t.Fatal("no anomaly reported")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func TestAnomalyDetectorInvalid(t *testing.T) {
+	if _, err := TryNew[int](1, time.Hour, func(i int) {}, WithAnomalyDetector(time.Second, 1, func(Anomaly) {})); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("factor 1: got %v, want ErrInvalidConfig", err)
+	}
+}
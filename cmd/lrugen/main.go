@@ -0,0 +1,103 @@
+// Command lrugen generates a thin, domain-named wrapper around an lru.Cache, for use with
+// go:generate. For example
+//
+//	//go:generate lrugen -type UserCache -name User -key string -value *User -o user_cache.go
+//
+// generates a UserCache type with NewUserCache, GetUser, PutUser and RemoveUser, backed by an
+// lru.Cache[string, *User].
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"text/template"
+)
+
+type spec struct {
+	Package string // package of the generated file
+	Type    string // name of the generated type
+	Name    string // name used in the method names
+	Key     string // key type
+	Value   string // value type
+	Import  string // import path of the lru package
+}
+
+var tmpl = template.Must(template.New("wrapper").Parse(`// Code generated by lrugen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"time"
+
+	lru "{{.Import}}"
+)
+
+// {{.Type}} is a cache of {{.Value}} values by {{.Key}}.
+type {{.Type}} struct {
+	c *lru.Cache[{{.Key}}, {{.Value}}]
+}
+
+// New{{.Type}} returns a {{.Type}}, see lru.New.
+func New{{.Type}}(size int, ttl time.Duration, onEvicted func({{.Value}}), opts ...lru.Option) *{{.Type}} {
+	return &{{.Type}}{c: lru.New[{{.Key}}](size, ttl, onEvicted, opts...)}
+}
+
+// Get{{.Name}} returns the {{.Name}} stored for k.
+func (c *{{.Type}}) Get{{.Name}}(k {{.Key}}) ({{.Value}}, bool) {
+	return c.c.Get(k)
+}
+
+// Put{{.Name}} stores v for k.
+func (c *{{.Type}}) Put{{.Name}}(k {{.Key}}, v {{.Value}}) error {
+	return c.c.Put(k, v)
+}
+
+// Remove{{.Name}} removes the {{.Name}} stored for k.
+func (c *{{.Type}}) Remove{{.Name}}(k {{.Key}}) {
+	c.c.Remove(k)
+}
+
+// Cache returns the underlying cache.
+func (c *{{.Type}}) Cache() *lru.Cache[{{.Key}}, {{.Value}}] {
+	return c.c
+}
+`))
+
+func generate(s spec) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, s); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+func main() {
+	var s spec
+	flag.StringVar(&s.Package, "package", os.Getenv("GOPACKAGE"), "package of the generated file, defaults to $GOPACKAGE")
+	flag.StringVar(&s.Type, "type", "", "name of the generated type")
+	flag.StringVar(&s.Name, "name", "", "name used in method names, e.g. User for GetUser")
+	flag.StringVar(&s.Key, "key", "", "key type")
+	flag.StringVar(&s.Value, "value", "", "value type")
+	flag.StringVar(&s.Import, "import", "go-lru", "import path of the lru package")
+	out := flag.String("o", "", "output file, defaults to stdout")
+	flag.Parse()
+	if s.Package == "" || s.Type == "" || s.Name == "" || s.Key == "" || s.Value == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	src, err := generate(s)
+	if err != nil {
+		log.Fatalf("lrugen: %v", err)
+	}
+	if *out == "" {
+		fmt.Print(string(src))
+		return
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatalf("lrugen: %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	src, err := generate(spec{
+		Package: "users",
+		Type:    "UserCache",
+		Name:    "User",
+		Key:     "string",
+		Value:   "*User",
+		Import:  "go-lru",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"func NewUserCache(size int, ttl time.Duration, onEvicted func(*User), opts ...lru.Option) *UserCache {",
+		"func (c *UserCache) GetUser(k string) (*User, bool) {",
+		"func (c *UserCache) PutUser(k string, v *User) error {",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated code does not contain %q:\n%s", want, src)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package lru
+
+import "context"
+
+// contextKey is unique per key and value type, so caches of different types can share a context.
+type contextKey[K comparable, V any] struct{}
+
+// NewContext returns a copy of ctx carrying c.
+func NewContext[K comparable, V any](ctx context.Context, c *Cache[K, V]) context.Context {
+	return context.WithValue(ctx, contextKey[K, V]{}, c)
+}
+
+// FromContext returns the Cache[K, V] stored in ctx by NewContext, if any.
+func FromContext[K comparable, V any](ctx context.Context) (*Cache[K, V], bool) {
+	c, ok := ctx.Value(contextKey[K, V]{}).(*Cache[K, V])
+	return c, ok
+}
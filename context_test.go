@@ -0,0 +1,18 @@
+package lru
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestContext(t *testing.T) {
+	c := New[string](1, time.Hour, func(i int) {})
+	ctx := NewContext(context.Background(), c)
+	if got, ok := FromContext[string, int](ctx); !ok || got != c {
+		t.Fatal("cache not found in context")
+	}
+	if _, ok := FromContext[string, string](ctx); ok {
+		t.Fatal("found a cache of the wrong type in context")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build lrudebug
+
+package lru
+
+// assert panics with msg if cond is false. It only does work in builds with the lrudebug tag.
+func assert(cond bool, msg string) {
+	if !cond {
+		panic("lru: assertion failed: " + msg)
+	}
+}
@@ -0,0 +1,29 @@
+package lru
+
+import "time"
+
+// Deduper remembers keys for a time window, for dropping duplicate deliveries in at-least-once
+// pipelines. It is built on a RollingCache of slices time slices covering the window, so a key is
+// remembered for at least window*(slices-1)/slices and at most window after it was last seen.
+type Deduper[K comparable] struct {
+	seen *RollingCache[K, int]
+}
+
+// NewDeduper returns a Deduper remembering keys for window, tracked in slices time slices.
+func NewDeduper[K comparable](window time.Duration, slices int) *Deduper[K] {
+	if slices <= 0 || window < time.Duration(slices) {
+		panic("Deduper: slices must be positive and window at least slices nanoseconds")
+	}
+	return &Deduper[K]{seen: NewRolling[K, int](slices, window/time.Duration(slices))}
+}
+
+// Seen records k and reports whether it was already seen within the window.
+func (d *Deduper[K]) Seen(k K) bool {
+	return d.SeenCount(k) > 1
+}
+
+// SeenCount records k and returns how many times it was seen, including this time. The count
+// restarts once k has not been seen for a window.
+func (d *Deduper[K]) SeenCount(k K) int {
+	return d.seen.Update(k, func(n int, _ bool) int { return n + 1 })
+}
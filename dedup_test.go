@@ -0,0 +1,36 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeduper(t *testing.T) {
+	d := NewDeduper[string](40*time.Millisecond, 2)
+	if d.Seen("a") {
+		t.Fatal("'a' should not have been seen yet")
+	}
+	if !d.Seen("a") {
+		t.Fatal("'a' should have been seen")
+	}
+	if n := d.SeenCount("a"); n != 3 {
+		t.Fatalf("'a' seen %d times, want 3", n)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if d.Seen("a") {
+		t.Fatal("'a' should have been forgotten")
+	}
+}
+
+func TestNewDeduperInvalid(t *testing.T) {
+	for _, slices := range []int{0, 10} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewDeduper(5ns, %d) should panic", slices)
+				}
+			}()
+			NewDeduper[string](5, slices)
+		}()
+	}
+}
@@ -0,0 +1,74 @@
+package lru
+
+import "time"
+
+// EvictReason describes why an entry left the cache.
+type EvictReason int
+
+const (
+	// Capacity means the entry was evicted to make room for a new one.
+	Capacity EvictReason = iota
+	// Removed means the entry was explicitly removed.
+	Removed
+	// Expired means the entry outlived its allowed lifetime.
+	Expired
+	// Replaced means the entry's value was overwritten by a Put or Move. Only the callback set by
+	// WithEvictionCallback is called for replaced values.
+	Replaced
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case Capacity:
+		return "capacity"
+	case Removed:
+		return "removed"
+	case Expired:
+		return "expired"
+	case Replaced:
+		return "replaced"
+	default:
+		return "unknown"
+	}
+}
+
+// Eviction records a key that left the cache.
+type Eviction[K any] struct {
+	Key    K
+	Tag    any // set by PutWithTag
+	Reason EvictReason
+	Time   time.Time
+}
+
+// evictionLog is a fixed size ring buffer of the most recent evictions.
+type evictionLog[K any] struct {
+	buf  []Eviction[K]
+	next int
+	full bool
+}
+
+func makeEvictionLog[K any](size int) evictionLog[K] {
+	return evictionLog[K]{buf: make([]Eviction[K], size)}
+}
+
+func (l *evictionLog[K]) record(k K, tag any, reason EvictReason) {
+	if len(l.buf) == 0 {
+		return
+	}
+	l.buf[l.next] = Eviction[K]{Key: k, Tag: tag, Reason: reason, Time: time.Now()}
+	l.next++
+	if l.next == len(l.buf) {
+		l.next = 0
+		l.full = true
+	}
+}
+
+// entries returns the recorded evictions, oldest first.
+func (l *evictionLog[K]) entries() []Eviction[K] {
+	if !l.full {
+		return append([]Eviction[K](nil), l.buf[:l.next]...)
+	}
+	out := make([]Eviction[K], 0, len(l.buf))
+	out = append(out, l.buf[l.next:]...)
+	return append(out, l.buf[:l.next]...)
+}
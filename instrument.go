@@ -0,0 +1,12 @@
+package lru
+
+// Instrumentation receives a cache's events as they happen, e.g. to record them with a metrics
+// library, see WithInstrumentation. Its methods are called with the cache locked: they must be
+// fast and must not use the cache.
+type Instrumentation interface {
+	Hit()
+	Miss()
+	Put()
+	// Evicted is called for every entry leaving the cache, and for every replaced value.
+	Evicted(reason EvictReason)
+}
@@ -0,0 +1,35 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+type countingInstrumentation struct {
+	hits, misses, puts int
+	evicted            map[EvictReason]int
+}
+
+func (c *countingInstrumentation) Hit()  { c.hits++ }
+func (c *countingInstrumentation) Miss() { c.misses++ }
+func (c *countingInstrumentation) Put()  { c.puts++ }
+
+func (c *countingInstrumentation) Evicted(reason EvictReason) {
+	c.evicted[reason]++
+}
+
+func TestInstrumentation(t *testing.T) {
+	inst := &countingInstrumentation{evicted: make(map[EvictReason]int)}
+	c := New[string](1, time.Hour, func(i int) {}, WithInstrumentation(inst))
+	c.Put("a", 1)
+	c.Get("a")
+	c.Put("b", 2)
+	c.Get("a")
+	c.Remove("b")
+	if inst.hits != 1 || inst.misses != 1 || inst.puts != 2 {
+		t.Fatalf("%d hits, %d misses, %d puts, want 1, 1, 2", inst.hits, inst.misses, inst.puts)
+	}
+	if inst.evicted[Capacity] != 1 || inst.evicted[Removed] != 1 {
+		t.Fatalf("evicted %v, want one for capacity and one removed", inst.evicted)
+	}
+}
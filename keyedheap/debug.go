@@ -0,0 +1,25 @@
+//go:build lrudebug
+
+package keyedheap
+
+import "fmt"
+
+// check panics if the heap's invariants don't hold. It only does work in builds with the
+// lrudebug tag, and is O(n).
+func (h *Heap[K, V]) check() {
+	s := h.nodes.s
+	if len(s) != len(h.keys) {
+		panic(fmt.Sprintf("keyedheap: %d nodes but %d keys", len(s), len(h.keys)))
+	}
+	for i, node := range s {
+		if node.index != i {
+			panic(fmt.Sprintf("keyedheap: node at %d has index %d", i, node.index))
+		}
+		if h.keys[node.k] != node {
+			panic(fmt.Sprintf("keyedheap: key %v does not map to its node", node.k))
+		}
+		if i > 0 && h.nodes.less(node.v, s[(i-1)/2].v) {
+			panic(fmt.Sprintf("keyedheap: node at %d is less than its parent", i))
+		}
+	}
+}
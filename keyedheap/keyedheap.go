@@ -0,0 +1,199 @@
+// Package keyedheap implements a min-heap whose elements are identified by unique keys, so that
+// they can be looked up, fixed and removed in O(log n) without tracking their position.
+package keyedheap
+
+import "container/heap"
+
+type node[K comparable, V any] struct {
+	k     K
+	v     V
+	index int
+}
+
+// nodes implements heap.Interface. Push, Pop, and Swap implementations are copied from the
+// PriorityQueue example of the container/heap doc page.
+type nodes[K comparable, V any] struct {
+	s        []*node[K, V]
+	less     func(a, b V) bool
+	counters *Counters
+}
+
+func (n nodes[K, V]) Len() int { return len(n.s) }
+
+func (n nodes[K, V]) Less(i, j int) bool { return n.less(n.s[i].v, n.s[j].v) }
+
+func (n nodes[K, V]) Swap(i, j int) {
+	s := n.s
+	s[i], s[j] = s[j], s[i]
+	s[i].index = i
+	s[j].index = j
+	if n.counters != nil {
+		n.counters.Swaps++
+	}
+}
+
+func (n *nodes[K, V]) Push(x any) {
+	node := x.(*node[K, V])
+	node.index = len(n.s)
+	n.s = append(n.s, node)
+}
+
+func (n *nodes[K, V]) Pop() any {
+	old := n.s
+	last := len(old) - 1
+	node := old[last]
+	old[last] = nil // avoid memory leak
+	node.index = -1 // for safety
+	n.s = old[:last]
+	return node
+}
+
+// Counters counts the operations performed on a Heap, see Heap.SetCounters.
+type Counters struct {
+	Pushes  uint64
+	Pops    uint64
+	Fixes   uint64
+	Removes uint64
+	Swaps   uint64
+	Shrinks uint64
+}
+
+// Heap is a min-heap of values ordered by a less function, each identified by a unique key.
+// A Heap must be created with New.
+type Heap[K comparable, V any] struct {
+	nodes nodes[K, V]
+	keys  map[K]*node[K, V]
+}
+
+// New returns an empty heap ordered by less, with room for size elements.
+func New[K comparable, V any](size int, less func(a, b V) bool) *Heap[K, V] {
+	return &Heap[K, V]{
+		nodes: nodes[K, V]{s: make([]*node[K, V], 0, size), less: less},
+		keys:  make(map[K]*node[K, V], size),
+	}
+}
+
+// SetCounters makes the heap count its operations in c, or stop counting if c is nil. Counters
+// can be shared by several heaps, for instance a heap and its replacement.
+func (h *Heap[K, V]) SetCounters(c *Counters) {
+	h.nodes.counters = c
+}
+
+// Len returns the number of elements in the heap.
+func (h *Heap[K, V]) Len() int { return len(h.nodes.s) }
+
+// Cap returns the number of elements the heap has room for before it grows.
+func (h *Heap[K, V]) Cap() int { return cap(h.nodes.s) }
+
+// Get returns the value for k.
+func (h *Heap[K, V]) Get(k K) (V, bool) {
+	node, exists := h.keys[k]
+	if !exists {
+		var v V
+		return v, false
+	}
+	return node.v, true
+}
+
+// Push adds v to the heap under k, replacing any value already there.
+func (h *Heap[K, V]) Push(k K, v V) {
+	if c := h.nodes.counters; c != nil {
+		c.Pushes++
+	}
+	if node, exists := h.keys[k]; exists {
+		node.v = v
+		heap.Fix(&h.nodes, node.index)
+		h.check()
+		return
+	}
+	node := &node[K, V]{k: k, v: v}
+	h.keys[k] = node
+	heap.Push(&h.nodes, node)
+	h.check()
+}
+
+// Peek returns the minimum element without removing it.
+func (h *Heap[K, V]) Peek() (K, V, bool) {
+	if len(h.nodes.s) == 0 {
+		var k K
+		var v V
+		return k, v, false
+	}
+	node := h.nodes.s[0]
+	return node.k, node.v, true
+}
+
+// PopMin removes and returns the minimum element.
+func (h *Heap[K, V]) PopMin() (K, V, bool) {
+	if len(h.nodes.s) == 0 {
+		var k K
+		var v V
+		return k, v, false
+	}
+	node := heap.Pop(&h.nodes).(*node[K, V])
+	if c := h.nodes.counters; c != nil {
+		c.Pops++
+	}
+	delete(h.keys, node.k)
+	h.check()
+	return node.k, node.v, true
+}
+
+// Fix re-establishes the heap ordering after the value for k changed in a way that affects its
+// order, e.g. through a pointer. It reports whether k is in the heap.
+func (h *Heap[K, V]) Fix(k K) bool {
+	node, exists := h.keys[k]
+	if exists {
+		if c := h.nodes.counters; c != nil {
+			c.Fixes++
+		}
+		heap.Fix(&h.nodes, node.index)
+		h.check()
+	}
+	return exists
+}
+
+// Remove removes and returns the value for k.
+func (h *Heap[K, V]) Remove(k K) (V, bool) {
+	node, exists := h.keys[k]
+	if !exists {
+		var v V
+		return v, false
+	}
+	heap.Remove(&h.nodes, node.index)
+	if c := h.nodes.counters; c != nil {
+		c.Removes++
+	}
+	delete(h.keys, k)
+	h.check()
+	return node.v, true
+}
+
+// At returns the i-th element in heap order, 0 <= i < Len(). Only At(0) is the minimum; the
+// order of the others is unspecified and changes as the heap does.
+func (h *Heap[K, V]) At(i int) (K, V) {
+	node := h.nodes.s[i]
+	return node.k, node.v
+}
+
+// Range calls fn for each element in heap order until fn returns false. fn must not modify the heap.
+func (h *Heap[K, V]) Range(fn func(K, V) bool) {
+	for _, node := range h.nodes.s {
+		if !fn(node.k, node.v) {
+			return
+		}
+	}
+}
+
+// Shrink reallocates the heap's internal structures to fit its current elements.
+func (h *Heap[K, V]) Shrink() {
+	if c := h.nodes.counters; c != nil {
+		c.Shrinks++
+	}
+	keys := make(map[K]*node[K, V], len(h.nodes.s))
+	for k, node := range h.keys {
+		keys[k] = node
+	}
+	h.keys = keys
+	h.nodes.s = append(make([]*node[K, V], 0, len(h.nodes.s)), h.nodes.s...)
+}
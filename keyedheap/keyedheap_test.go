@@ -0,0 +1,87 @@
+package keyedheap
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+func less(a, b int) bool { return a < b }
+
+func TestHeap(t *testing.T) {
+	h := New[string](0, less)
+	for _, v := range []int{5, 3, 8, 1} {
+		h.Push(strconv.Itoa(v), v)
+	}
+	if k, v, _ := h.Peek(); k != "1" || v != 1 {
+		t.Fatalf("Peek returned %s: %d, want 1: 1", k, v)
+	}
+	h.Push("8", 0)
+	if v, _ := h.Get("8"); v != 0 {
+		t.Fatalf("'8' value %d is not 0", v)
+	}
+	if v, ok := h.Remove("3"); !ok || v != 3 {
+		t.Fatalf("Remove returned %d, %v", v, ok)
+	}
+	var got []string
+	for h.Len() > 0 {
+		k, _, _ := h.PopMin()
+		got = append(got, k)
+	}
+	if s := fmt.Sprint(got); s != "[8 1 5]" {
+		t.Fatalf("popped %s, want [8 1 5]", s)
+	}
+	if _, _, ok := h.PopMin(); ok {
+		t.Fatal("PopMin on an empty heap should report false")
+	}
+}
+
+func TestFix(t *testing.T) {
+	type elem struct{ prio int }
+	h := New[string](0, func(a, b *elem) bool { return a.prio < b.prio })
+	a, b := &elem{1}, &elem{2}
+	h.Push("a", a)
+	h.Push("b", b)
+	a.prio = 3
+	if !h.Fix("a") {
+		t.Fatal("'a' should be in the heap")
+	}
+	if k, _, _ := h.Peek(); k != "b" {
+		t.Fatalf("minimum is %s, want b", k)
+	}
+	if h.Fix("c") {
+		t.Fatal("'c' should not be in the heap")
+	}
+}
+
+func TestShrink(t *testing.T) {
+	h := New[int](100, less)
+	for i := 0; i < 100; i++ {
+		h.Push(i, i)
+	}
+	for i := 0; i < 98; i++ {
+		h.PopMin()
+	}
+	h.Shrink()
+	if n := h.Cap(); n != 2 {
+		t.Fatalf("capacity %d is not 2", n)
+	}
+	if k, _, _ := h.PopMin(); k != 98 {
+		t.Fatalf("minimum is %d, want 98", k)
+	}
+}
+
+func TestCounters(t *testing.T) {
+	var c Counters
+	h := New[int](0, less)
+	h.SetCounters(&c)
+	h.Push(1, 1)
+	h.Push(2, 0)
+	h.Fix(1)
+	h.Remove(2)
+	h.PopMin()
+	h.Shrink()
+	if c.Pushes != 2 || c.Fixes != 1 || c.Removes != 1 || c.Pops != 1 || c.Shrinks != 1 || c.Swaps == 0 {
+		t.Fatalf("unexpected counters %+v", c)
+	}
+}
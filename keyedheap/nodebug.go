@@ -0,0 +1,5 @@
+//go:build !lrudebug
+
+package keyedheap
+
+func (h *Heap[K, V]) check() {}
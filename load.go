@@ -0,0 +1,195 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrLoadPanicked is returned by GetOrLoad to callers that waited for a load that panicked.
+var ErrLoadPanicked = errors.New("lru: load panicked")
+
+// loadCall is a GetOrLoad load in progress, for other callers to wait for.
+type loadCall[V any] struct {
+	done  chan struct{} // closed once v and err are set
+	v     V
+	err   error
+	stale bool // k was put or removed during the load, its result must not be stored
+}
+
+// failure is a load error cached by WithErrorTTL.
+type failure struct {
+	err    error
+	expire time.Time
+}
+
+// fail caches err as the outcome of loading k, making room among the cache's failures if needed.
+// At most as many failures are kept as the cache holds entries.
+func (c *Cache[K, V]) fail(k K, err error) {
+	if c.size == 0 {
+		return
+	}
+	now := time.Now()
+	if _, exists := c.failures[k]; !exists && len(c.failures) >= c.size {
+		for old, f := range c.failures {
+			if !now.Before(f.expire) {
+				delete(c.failures, old)
+			}
+		}
+		for old := range c.failures {
+			if len(c.failures) < c.size {
+				break
+			}
+			delete(c.failures, old)
+		}
+	}
+	if c.failures == nil {
+		c.failures = make(map[K]failure)
+	}
+	c.failures[k] = failure{err, now.Add(c.errorTTL)}
+}
+
+// failed returns the cached error of loading k, if it has not expired.
+func (c *Cache[K, V]) failed(k K) (error, bool) {
+	f, failed := c.failures[k]
+	if !failed {
+		return nil, false
+	}
+	if !time.Now().Before(f.expire) {
+		delete(c.failures, k)
+		return nil, false
+	}
+	return f.err, true
+}
+
+// invalidateLoad keeps a load of k in progress from storing its result over a newer Put or Remove,
+// or an entry brought in by Move or Restore.
+func (c *Cache[K, V]) invalidateLoad(k K) {
+	if l, loading := c.loads[k]; loading {
+		l.stale = true
+	}
+}
+
+// invalidateLoads invalidates every load in progress, when the whole cache is emptied or replaced.
+func (c *Cache[K, V]) invalidateLoads() {
+	for _, l := range c.loads {
+		l.stale = true
+	}
+}
+
+// GetOrLoad returns the value stored for k, refreshing it like Get. If there is none, it calls
+// load, stores the value it returns and returns it. Concurrent GetOrLoads for the same k wait for
+// a single load and share its result. If load returns an error, nothing is stored and the error is
+// returned. If storing the value fails, e.g. because k was meanwhile put with PutReadOnly, the
+// value is returned along with the error. If k is put, removed, moved or restored while load runs,
+// the loaded value is returned but not stored. load is called without the cache locked, so it may
+// use the cache. With WithErrorTTL, errors are cached too, see GetResult.
+func (c *Cache[K, V]) GetOrLoad(k K, load func(K) (V, error)) (V, error) {
+	return c.GetOrLoadContext(context.Background(), k, func(_ context.Context, k K) (V, error) {
+		return load(k)
+	})
+}
+
+// GetOrLoadContext is like GetOrLoad, but gives up with ctx.Err() once ctx is done while waiting
+// for a load slot (see WithLoadLimit) or for another caller's load of k. ctx is passed on to load.
+// Callers sharing a load share its result, including an error caused by the loading caller's ctx.
+func (c *Cache[K, V]) GetOrLoadContext(ctx context.Context, k K, load func(context.Context, K) (V, error)) (V, error) {
+	c.lock()
+	if item, exists := c.get(k); exists {
+		defer c.mu.Unlock()
+		return item.v, nil
+	}
+	if err, failed := c.failed(k); failed {
+		c.mu.Unlock()
+		var v V
+		return v, err
+	}
+	if l, loading := c.loads[k]; loading {
+		c.mu.Unlock()
+		select {
+		case <-l.done:
+			return l.v, l.err
+		case <-ctx.Done():
+			var v V
+			return v, ctx.Err()
+		}
+	}
+	l := &loadCall[V]{done: make(chan struct{})}
+	if c.loads == nil {
+		c.loads = make(map[K]*loadCall[V])
+	}
+	c.loads[k] = l
+	c.mu.Unlock()
+	c.load(ctx, k, l, load)
+	return l.v, l.err
+}
+
+// load calls fn for k, once a load slot is free, and stores its result, then releases the callers
+// waiting for it.
+func (c *Cache[K, V]) load(ctx context.Context, k K, l *loadCall[V], fn func(context.Context, K) (V, error)) {
+	panicked := true
+	var took time.Duration
+	defer func() {
+		c.lock()
+		delete(c.loads, k)
+		if panicked {
+			l.err = ErrLoadPanicked
+		} else if l.err == nil && !l.stale {
+			var stored *item[K, V]
+			if stored, l.err = c.put(k, l.v, 0); l.err == nil {
+				// return the value as stored, after WithTransform.
+				l.v = stored.v
+				stored.loadTime = took
+			}
+		} else if l.err != nil && !l.stale && c.errorTTL > 0 && ctx.Err() == nil {
+			c.fail(k, l.err)
+		}
+		c.mu.Unlock()
+		close(l.done)
+	}()
+	if c.loadSlots != nil {
+		select {
+		case c.loadSlots <- struct{}{}:
+			defer func() { <-c.loadSlots }()
+		case <-ctx.Done():
+			l.err = ctx.Err()
+			panicked = false
+			return
+		}
+	}
+	start := time.Now()
+	l.v, l.err = fn(ctx, k)
+	took = time.Since(start)
+	panicked = false
+	c.stats.loads.Add(1)
+	c.stats.loadTime.Add(uint64(took))
+}
+
+// LoadLatency returns how long GetOrLoad took to load the value stored for k, which is what it
+// costs to get it again once evicted. It reports false if k is missing or its value was Put.
+func (c *Cache[K, V]) LoadLatency(k K) (time.Duration, bool) {
+	c.lock()
+	defer c.mu.Unlock()
+	item, exists := c.items.Get(k)
+	if !exists || item.loadTime == 0 {
+		return 0, false
+	}
+	return item.loadTime, true
+}
+
+// GetResult returns the outcome of loading k: the value stored for k, refreshed like Get, with a nil
+// error, or the zero value with the error of a failed load cached by WithErrorTTL. It reports
+// false if there is neither.
+func (c *Cache[K, V]) GetResult(k K) (V, error, bool) {
+	c.lock()
+	defer c.mu.Unlock()
+	var v V
+	if err, failed := c.failed(k); failed {
+		return v, err, true
+	}
+	item, exists := c.get(k)
+	if !exists {
+		return v, nil, false
+	}
+	return item.v, nil, true
+}
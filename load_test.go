@@ -0,0 +1,180 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {})
+	loads := 0
+	load := func(k string) (int, error) {
+		loads++
+		return len(k), nil
+	}
+	for i := 0; i < 2; i++ {
+		if v, err := c.GetOrLoad("abc", load); err != nil || v != 3 {
+			t.Fatalf("GetOrLoad returned %d, %v, want 3", v, err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("loaded %d times, want once", loads)
+	}
+	errLoad := errors.New("load failed")
+	if _, err := c.GetOrLoad("x", func(string) (int, error) { return 0, errLoad }); err != errLoad {
+		t.Fatalf("got error %v, want %v", err, errLoad)
+	}
+	if c.Contains("x") {
+		t.Fatal("a failed load should not be stored")
+	}
+}
+
+func TestGetOrLoadConcurrent(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {})
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(string) (int, error) {
+		loads.Add(1)
+		<-release
+		return 1, nil
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := c.GetOrLoad("a", load); err != nil || v != 1 {
+				t.Errorf("GetOrLoad returned %d, %v, want 1", v, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("loaded %d times, want once", n)
+	}
+}
+
+func TestGetOrLoadTransform(t *testing.T) {
+	c := New[string](2, time.Hour, func(s string) {}, WithTransform(func(s string) (string, error) { return s + "!", nil }))
+	v, err := c.GetOrLoad("a", func(string) (string, error) { return "x", nil })
+	if err != nil || v != "x!" {
+		t.Fatalf("GetOrLoad returned %q, %v, want the stored x!", v, err)
+	}
+}
+
+func TestGetOrLoadPutDuringLoad(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {})
+	c.GetOrLoad("a", func(string) (int, error) {
+		c.Put("a", 2)
+		return 1, nil
+	})
+	if v, _ := c.Get("a"); v != 2 {
+		t.Fatalf("'a' value %d is not the newer 2", v)
+	}
+	c.GetOrLoad("b", func(string) (int, error) {
+		c.Put("b", 2)
+		c.Remove("b")
+		return 1, nil
+	})
+	if c.Contains("b") {
+		t.Fatal("a load should not store over a Remove")
+	}
+}
+
+func TestGetOrLoadMoveDuringLoad(t *testing.T) {
+	src := New[string](2, time.Hour, func(i int) {})
+	dst := New[string](2, time.Hour, func(i int) {})
+	src.Put("k", 1)
+	dst.GetOrLoad("k", func(string) (int, error) {
+		src.Move(dst, "k")
+		return 99, nil
+	})
+	if v, _ := dst.Get("k"); v != 1 {
+		t.Fatalf("'k' value %d is not the moved 1", v)
+	}
+}
+
+func TestLoadLimit(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {}, WithLoadLimit(1))
+	started, release := make(chan struct{}), make(chan struct{})
+	go c.GetOrLoad("a", func(string) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.GetOrLoadContext(ctx, "b", func(context.Context, string) (int, error) {
+		t.Error("'b' loaded while 'a' held the only load slot")
+		return 2, nil
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("queued load returned %v, want context.DeadlineExceeded", err)
+	}
+	if _, err := c.GetOrLoadContext(ctx, "a", nil); err != context.DeadlineExceeded {
+		t.Fatalf("waiting for 'a' returned %v, want context.DeadlineExceeded", err)
+	}
+	close(release)
+	v, err := c.GetOrLoad("b", func(string) (int, error) { return 2, nil })
+	if err != nil || v != 2 {
+		t.Fatalf("GetOrLoad returned %d, %v, want 2", v, err)
+	}
+	if _, err := TryNew[string](1, time.Hour, func(i int) {}, WithLoadLimit(-1)); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("negative load limit: got %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestErrorTTL(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {}, WithErrorTTL(20*time.Millisecond))
+	errLoad := errors.New("load failed")
+	loads := 0
+	load := func(string) (int, error) {
+		loads++
+		return 0, errLoad
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetOrLoad("a", load); err != errLoad {
+			t.Fatalf("GetOrLoad returned %v, want %v", err, errLoad)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("loaded %d times, want the error cached after once", loads)
+	}
+	if _, err, ok := c.GetResult("a"); !ok || err != errLoad {
+		t.Fatalf("GetResult returned %v, %v, want the cached error", err, ok)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, _, ok := c.GetResult("a"); ok {
+		t.Fatal("the cached error should have expired")
+	}
+	c.GetOrLoad("a", load)
+	c.Put("a", 1)
+	if v, err, ok := c.GetResult("a"); !ok || err != nil || v != 1 {
+		t.Fatalf("GetResult returned %d, %v, %v, want the value put over the error", v, err, ok)
+	}
+}
+
+func TestLoadLatency(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {})
+	c.GetOrLoad("a", func(string) (int, error) {
+		time.Sleep(5 * time.Millisecond)
+		return 1, nil
+	})
+	if d, ok := c.LoadLatency("a"); !ok || d < 5*time.Millisecond {
+		t.Fatalf("load latency %v, %v, want at least 5ms", d, ok)
+	}
+	if s := c.Stats(); s.Loads != 1 || s.LoadTime < 5*time.Millisecond {
+		t.Fatalf("stats %+v, want 1 load of at least 5ms", s)
+	}
+	c.Put("a", 2)
+	if _, ok := c.LoadLatency("a"); ok {
+		t.Fatal("a value put over a loaded one has no load latency")
+	}
+}
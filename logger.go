@@ -0,0 +1,17 @@
+//go:build go1.21
+
+package lru
+
+import "log/slog"
+
+// WithLogger makes the cache log entries leaving it, with their key, reason and age, resizes and
+// janitor runs to logger at debug level.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		if logger == nil {
+			o.invalid = "logger must not be nil"
+			return
+		}
+		o.debugLog = logger.Debug
+	}
+}
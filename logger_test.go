@@ -0,0 +1,36 @@
+//go:build go1.21
+
+package lru
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := New[string](1, time.Hour, func(i int) {}, WithLogger(logger))
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Resize(0)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("logged %q, want 3 lines", lines)
+	}
+	for i, want := range []string{"key=a reason=capacity", "key=b reason=capacity", "old=1 new=0 evicted=1"} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("line %q does not contain %q", lines[i], want)
+		}
+	}
+}
+
+func TestNilLogger(t *testing.T) {
+	if _, err := TryNew[string](1, time.Hour, func(i int) {}, WithLogger(nil)); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("nil logger: got %v, want ErrInvalidConfig", err)
+	}
+}
@@ -1,157 +1,1377 @@
 package lru
 
 import (
-	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"go-lru/keyedheap"
 )
 
 type item[K any, V any] struct {
-	k      K
-	v      V
-	expire time.Time
-	index  int
-}
+	k         K
+	v         V
+	expire    time.Time
+	refreshed time.Time // when expire was last computed
+	created   time.Time
+	tag       any
+	pinUntil  time.Time // zero if not pinned
+	readOnly  bool
+	ttl       time.Duration // 0 for the cache's ttl
+	extended  int           // refreshes by Get since the value was Put
+	read      bool          // whether a lookup returned the value since it was Put
+	loadTime  time.Duration // how long GetOrLoad took to load the value, 0 if it was Put
 
-// kvHeap implements the heap.Interface and maintains a mapping from K keys to items.
-// Push, Pop, and Swap implementations are copied from the PriorityQueue example of the container/heap
-// doc page but modified to keep the keyToItem map up to date.
-type kvHeap[K comparable, V any] struct {
-	keyToItem map[K]*item[K, V] // maps a key to a *item
-	pq        []*item[K, V]     // priority queue of *item ordered by expiration time
+	prev, next *item[K, V] // neighbors in the cache's recency list
 }
 
-func makeKVHeap[K comparable, V any](size int) kvHeap[K, V] {
-	h := kvHeap[K, V]{
-		keyToItem: make(map[K]*item[K, V], size),
-		pq:        make([]*item[K, V], 0, size),
-	}
-	heap.Init(&h)
-	return h
+func expiresBefore[K any, V any](a, b *item[K, V]) bool {
+	return a.expire.Before(b.expire)
 }
 
-func (h kvHeap[K, V]) Len() int { return len(h.pq) }
+// forever is the pinUntil of entries pinned without a lease.
+var forever = time.Unix(1<<62, 0)
 
-func (h kvHeap[K, V]) Less(i, j int) bool {
-	a, b := h.pq[i], h.pq[j]
-	return a.expire.Before(b.expire)
+func (item *item[K, V]) pinned(now time.Time) bool {
+	return now.Before(item.pinUntil)
 }
 
-func (h kvHeap[K, V]) Swap(i, j int) {
-	pq := h.pq
-	pq[i], pq[j] = pq[j], pq[i]
-	pq[i].index = i
-	pq[j].index = j
-}
+// NoExpiry can be used as a ttl for entries that should only leave the cache through eviction or
+// removal.
+const NoExpiry time.Duration = math.MaxInt64
 
-func (h *kvHeap[K, V]) Push(x any) {
-	n := len(h.pq)
-	item := x.(*item[K, V])
-	item.index = n
-	h.keyToItem[item.k] = item
-	h.pq = append(h.pq, item)
-}
+// ErrInvalidTTL is returned for negative TTLs.
+var ErrInvalidTTL = errors.New("lru: invalid ttl")
 
-func (h *kvHeap[K, V]) Pop() any {
-	old := h.pq
-	n := len(old)
-	item := old[n-1]
-	old[n-1] = nil  // avoid memory leak
-	item.index = -1 // for safety
-	h.pq = old[0 : n-1]
-	delete(h.keyToItem, item.k)
-	return item
-}
+// ErrInvalidConfig is returned by TryNew for an invalid size or option.
+var ErrInvalidConfig = errors.New("lru: invalid configuration")
 
-func (h *kvHeap[K, V]) Item(k K) (item *item[K, V], exists bool) {
-	item, exists = h.keyToItem[k]
-	return
-}
+// ErrReadOnly is returned when replacing an entry that was put with PutReadOnly. Update doesn't
+// modify such entries either.
+var ErrReadOnly = errors.New("lru: entry is read-only")
 
 type Cache[K comparable, V any] struct {
 	mu        sync.Mutex
-	items     kvHeap[K, V]
+	items     *keyedheap.Heap[K, *item[K, V]] // ordered by expiration time
 	size      int
 	ttl       time.Duration
 	onEvicted func(V)
+	onEvict   func(K, V, EvictReason, any) // set by WithEvictionCallback
+	evictions evictionLog[K]
+	stats     counters
+	bypass    atomic.Uint64 // math.Float64bits of the bypass probability
+
+	maxLifetime time.Duration
+	coalesce    time.Duration
+	low, high   int // eviction watermarks, high is 0 for the defaults
+	validator   func(K, V) bool
+	overflow    func(K, V) bool
+	canonical   func(K) K // set by WithCanonicalKeys
+	transforms  []func(V) (V, error)
+	samples     int
+	align       time.Duration
+	fifo        bool
+	extensions  int // max refreshes by Get per Put, 0 for unlimited
+
+	minResidency time.Duration // entries younger than this are not evicted for capacity
+
+	debugLog func(msg string, args ...any) // set by WithLogger
+
+	restoreWindow time.Duration
+	removed       map[K]*softRemoval[K, V] // removed by RemoveSoft, restorable
+
+	loads     map[K]*loadCall[V] // GetOrLoad loads in progress
+	loadSlots chan struct{}      // one element per load running, nil unless WithLoadLimit
+	errorTTL  time.Duration      // how long GetOrLoad caches load errors, 0 for not at all
+	failures  map[K]failure      // load errors cached by WithErrorTTL
+
+	warmAt      int           // number of entries after which the cache is warm
+	warmRatio   float64       // hit ratio after which the cache is warm
+	warmLookups uint64        // lookups needed before warmRatio is checked
+	warm        chan struct{} // closed once the cache is warm
+
+	heapCounters *keyedheap.Counters // nil unless WithDebugStats
+
+	// items orders entries by expiration, recency by use. Every entry is in both, and in index
+	// if the cache has one.
+	recency recency[K, V]
+	index   keyIndex[K] // set by WithOrderedIndex
+	shadow  *shadow[K]  // set by WithShadow
+
+	done      chan struct{} // closed by Close to stop the janitor and anomaly detector
+	closeOnce sync.Once
 }
 
-func New[K comparable, V any](size int, ttl time.Duration, onEvicted func(V)) *Cache[K, V] {
-	if size <= 0 {
-		panic("Cache: cannot have 0 or negative size")
+// New returns a cache holding at most size entries, each expiring ttl after it was last accessed.
+// A ttl of NoExpiry disables expiration.
+// onEvicted, if not nil, is called with every value that leaves the cache. A size of 0 disables
+// caching: Put immediately evicts the value and Get always misses.
+func New[K comparable, V any](size int, ttl time.Duration, onEvicted func(V), opts ...Option) *Cache[K, V] {
+	c, problem := newCache[K](size, ttl, onEvicted, opts)
+	if problem != "" {
+		panic("Cache: " + problem)
 	}
-	return &Cache[K, V]{
+	return c
+}
+
+// TryNew is like New, for caches configured at run time: instead of panicking, it returns an
+// error wrapping ErrInvalidConfig if size or an option is invalid. It also returns ErrInvalidTTL
+// unless ttl is positive.
+func TryNew[K comparable, V any](size int, ttl time.Duration, onEvicted func(V), opts ...Option) (*Cache[K, V], error) {
+	if ttl <= 0 {
+		return nil, ErrInvalidTTL
+	}
+	c, problem := newCache[K](size, ttl, onEvicted, opts)
+	if problem != "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidConfig, problem)
+	}
+	return c, nil
+}
+
+// newCache returns a new cache, or a description of what is wrong with its configuration.
+func newCache[K comparable, V any](size int, ttl time.Duration, onEvicted func(V), opts []Option) (*Cache[K, V], string) {
+	if size < 0 {
+		return nil, "cannot have negative size"
+	}
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.invalid != "" {
+		return nil, o.invalid
+	}
+	var problem string
+	c := &Cache[K, V]{
 		size:      size,
-		items:     makeKVHeap[K, V](size),
 		ttl:       ttl,
 		onEvicted: onEvicted,
+		evictions: makeEvictionLog[K](o.recentlyEvicted),
+
+		maxLifetime: o.maxLifetime,
+		coalesce:    o.coalesce,
+		low:         o.low,
+		high:        o.high,
+		validator:   hook[func(K, V) bool](o.validator, "validator", &problem),
+		overflow:    hook[func(K, V) bool](o.overflow, "overflow handler", &problem),
+		canonical:   hook[func(K) K](o.canonical, "key canonicalization", &problem),
+		onEvict:     hook[func(K, V, EvictReason, any)](o.onEvict, "eviction callback", &problem),
+		align:       o.align,
+		fifo:        o.fifo,
+		samples:     o.samples,
+		extensions:  o.extensions,
+
+		minResidency: o.minResidency,
+		debugLog:     o.debugLog,
+
+		restoreWindow: o.restoreWindow,
+		errorTTL:      o.errorTTL,
+
+		warmAt:      o.warmAt,
+		warmRatio:   o.warmRatio,
+		warmLookups: uint64(o.warmLookups),
+		warm:        make(chan struct{}),
+	}
+	for _, t := range o.transforms {
+		c.transforms = append(c.transforms, hook[func(V) (V, error)](t, "transform", &problem))
+	}
+	newIndex := hook[func() keyIndex[K]](o.index, "ordered index", &problem)
+	if problem != "" {
+		return nil, problem
+	}
+	if o.debugStats {
+		c.heapCounters = new(keyedheap.Counters)
+	}
+	if newIndex != nil {
+		c.index = newIndex()
+	}
+	if o.shadow > 0 {
+		c.shadow = newShadow[K](o.shadow)
+	}
+	c.reset(c.size)
+	c.stats.inst = o.inst
+	if c.warmAt <= 0 && c.warmRatio <= 0 {
+		close(c.warm)
+	}
+	if o.loadLimit > 0 {
+		c.loadSlots = make(chan struct{}, o.loadLimit)
+	}
+	if o.janitor > 0 || o.anomalies != nil {
+		c.done = make(chan struct{})
+	}
+	if o.janitor > 0 {
+		go c.janitor(o.janitor, o.sweepLimit)
+	}
+	if o.anomalies != nil {
+		go c.detect(o.detectInterval, o.anomalyFactor, o.anomalies)
+	}
+	return c, ""
+}
+
+// reset empties the cache's structures, making room for size entries.
+func (c *Cache[K, V]) reset(size int) {
+	c.items = keyedheap.New[K](size, expiresBefore[K, V])
+	c.items.SetCounters(c.heapCounters)
+	c.recency.init()
+	if c.index != nil {
+		c.index.reset()
+	}
+	if c.shadow != nil {
+		c.shadow.reset()
 	}
+	c.failures = nil
 }
 
-func (c *Cache[K, V]) evict() {
-	x := heap.Pop(&c.items)
-	if x == nil {
-		panic("evict called with empty heap")
+// hook returns the function f set by an option. If f doesn't match the cache's types, it sets
+// *problem, unless a problem was found already.
+func hook[F any](f any, name string, problem *string) F {
+	if f == nil {
+		var zero F
+		return zero
 	}
-	evict := x.(*item[K, V])
-	c.onEvicted(evict.v)
+	fn, ok := f.(F)
+	if !ok && *problem == "" {
+		*problem = name + " does not match the cache's key and value types"
+	}
+	return fn
+}
+
+// lock acquires c.mu, recording how long it waited if the mutex was contended.
+func (c *Cache[K, V]) lock() {
+	if c.mu.TryLock() {
+		return
+	}
+	start := time.Now()
+	c.mu.Lock()
+	c.stats.lockContentions.Add(1)
+	c.stats.lockWait.Add(uint64(time.Since(start)))
+}
+
+// victim returns the entry to evict next, or nil if there is none: the first to expire if it
+// already has, otherwise the least recently used entry that isn't protected. With
+// WithRandomEviction it is the first to expire of a random sample instead, if it has one unprotected.
+func (c *Cache[K, V]) victim() *item[K, V] {
+	now := time.Now()
+	if _, first, ok := c.items.Peek(); ok && !c.live(first, now) && !c.protected(first, now) {
+		return first
+	}
+	if c.samples > 0 && c.items.Len() > c.samples {
+		if victim := c.sampleVictim(); victim != nil {
+			return victim
+		}
+	}
+	for item := c.recency.back(); item != nil; item = c.recency.newer(item) {
+		if !c.protected(item, now) {
+			return item
+		}
+	}
+	return nil
+}
+
+func (c *Cache[K, V]) sampleVictim() *item[K, V] {
+	now := time.Now()
+	var victim *item[K, V]
+	for i := 0; i < c.samples; i++ {
+		_, item := c.items.At(rand.Intn(c.items.Len()))
+		if !c.protected(item, now) && (victim == nil || expiresBefore(item, victim)) {
+			victim = item
+		}
+	}
+	return victim
+}
+
+// minShrinkCap is the capacity below which shrinkIfSparse leaves the cache's items alone, as
+// reallocating them would save little.
+const minShrinkCap = 64
+
+// shrinkIfSparse reallocates the cache's items if a bulk removal of removed entries left less than
+// a quarter of their room in use. See ShrinkToFit.
+func (c *Cache[K, V]) shrinkIfSparse(removed int) {
+	if n := c.items.Cap(); removed > 0 && n > minShrinkCap && c.items.Len() < n/4 {
+		c.items.Shrink()
+	}
+}
+
+// protected reports whether item is exempt from capacity eviction, being pinned or younger than
+// the minimum residency.
+func (c *Cache[K, V]) protected(item *item[K, V], now time.Time) bool {
+	return item.pinned(now) || c.minResidency > 0 && now.Sub(item.created) < c.minResidency
+}
+
+// evict evicts the entry chosen by victim, and reports whether there was one.
+func (c *Cache[K, V]) evict() bool {
+	victim := c.victim()
+	if victim == nil {
+		return false
+	}
+	c.unlink(victim)
+	c.evicted(victim)
+	return true
 }
 
-func (c *Cache[K, V]) update(item *item[K, V], v V) {
+// unlink removes item from the expiry heap, the recency list and the ordered index.
+func (c *Cache[K, V]) unlink(item *item[K, V]) {
+	c.items.Remove(item.k)
+	c.recency.remove(item)
+	if c.index != nil {
+		c.index.remove(item.k)
+	}
+}
+
+// evicted accounts for item having been evicted to make room.
+func (c *Cache[K, V]) evicted(item *item[K, V]) {
+	c.record(item, Capacity)
+	if c.overflow != nil && c.overflow(item.k, item.v) {
+		return
+	}
+	c.notify(item, item.v, Capacity)
+}
+
+// record accounts for item leaving the cache for reason.
+func (c *Cache[K, V]) record(item *item[K, V], reason EvictReason) {
+	c.stats.count(reason)
+	c.stats.dropped(item.read)
+	c.evictions.record(item.k, item.tag, reason)
+	if c.debugLog != nil {
+		c.debugLog("lru: entry left the cache", "key", item.k, "reason", reason, "age", time.Since(item.created))
+	}
+}
+
+// notify calls the eviction callbacks for v, stored in item, leaving the cache for reason.
+func (c *Cache[K, V]) notify(item *item[K, V], v V, reason EvictReason) {
+	if reason != Replaced && c.onEvicted != nil {
+		c.onEvicted(v)
+	}
+	if c.onEvict != nil {
+		c.onEvict(item.k, v, reason, item.tag)
+	}
+}
+
+// replaced accounts for old, the value of item, having been replaced. Unlike entries leaving the
+// cache, replaced values are not recorded in RecentlyEvicted or the debug log.
+func (c *Cache[K, V]) replaced(item *item[K, V], old V) {
+	c.stats.count(Replaced)
+	c.stats.dropped(item.read)
+	c.notify(item, old, Replaced)
+}
+
+// watermarks returns the number of entries at which adding another evicts, and the number of
+// entries eviction leaves behind. By default the cache evicts a single entry when it is full.
+func (c *Cache[K, V]) watermarks() (low, high int) {
+	high = c.size
+	if c.high > 0 && c.high < high {
+		high = c.high
+	}
+	low = high - 1
+	if c.high > 0 && c.low < low {
+		// set by WithEvictionWatermarks, where low may be 0.
+		low = c.low
+	}
+	return low, high
+}
+
+func (c *Cache[K, V]) update(item *item[K, V], v V, ttl time.Duration) {
+	now := time.Now()
+	old := item.v
 	item.v = v
+	item.created = now
+	item.extended = 0
+	item.loadTime = 0
+	c.replaced(item, old)
+	item.read = false
+	if ttl != item.ttl {
+		item.ttl = ttl
+		c.refresh(item)
+		return
+	}
+	if c.fifo || c.coalesce > 0 && now.Sub(item.refreshed) < c.coalesce {
+		// refreshed recently enough, skip reordering the heap.
+		return
+	}
 	c.refresh(item)
 }
 
+// expiresAt returns when an entry with the given ttl, refreshed at now, expires.
+func (c *Cache[K, V]) expiresAt(now time.Time, ttl time.Duration) time.Time {
+	if ttl == 0 {
+		ttl = c.ttl
+	}
+	// NoExpiry needs no special case: it puts expire centuries away, still ordered by access time.
+	expire := now.Add(ttl)
+	if c.align > 0 {
+		if t := expire.Truncate(c.align); t.Before(expire) {
+			expire = t.Add(c.align)
+		}
+	}
+	return expire
+}
+
 func (c *Cache[K, V]) refresh(item *item[K, V]) {
-	item.expire = time.Now().Add(c.ttl)
-	heap.Fix(&c.items, item.index)
+	now := time.Now()
+	item.expire = c.expiresAt(now, item.ttl)
+	item.refreshed = now
+	assert(!item.expire.Before(now), "refresh moved expiration into the past")
+	c.items.Fix(item.k)
+	if !c.fifo {
+		// with WithFIFO, the recency list stays in insertion order.
+		c.recency.moveToFront(item)
+	}
 }
 
+// add adds a new item, evicting other entries to make room as needed.
 func (c *Cache[K, V]) add(item *item[K, V]) {
-	heap.Push(&c.items, item)
+	low, high := c.watermarks()
+	if c.items.Len() >= high {
+		for c.items.Len() > low && c.evict() {
+		}
+	}
+	if c.items.Len() >= high {
+		// caching is disabled or every entry is pinned, there is no room for item.
+		c.evicted(item)
+		return
+	}
+	if c.canonical != nil {
+		item.k = c.canonical(item.k)
+	}
+	c.items.Push(item.k, item)
+	c.recency.pushFront(item)
+	if c.index != nil {
+		c.index.insert(item.k)
+	}
+	assert(c.items.Len() <= c.size, "cache grew past its size")
+	if c.warmAt > 0 && c.items.Len() >= c.warmAt {
+		c.warmUp()
+	}
 }
 
-func (c *Cache[K, V]) delete(item *item[K, V]) {
-	heap.Remove(&c.items, item.index)
-	c.onEvicted(item.v)
+// lifetimeExceeded reports whether item has outlived the cache's max lifetime.
+func (c *Cache[K, V]) lifetimeExceeded(item *item[K, V], now time.Time) bool {
+	return c.maxLifetime > 0 && now.Sub(item.created) > c.maxLifetime
 }
 
-func (c *Cache[K, V]) Put(k K, v V) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	if item, exists := c.items.Item(k); exists {
-		c.update(item, v)
-		return
+// live reports whether item has neither expired nor exceeded its max lifetime.
+func (c *Cache[K, V]) live(item *item[K, V], now time.Time) bool {
+	return now.Before(item.expire) && !c.lifetimeExceeded(item, now)
+}
+
+func (c *Cache[K, V]) delete(item *item[K, V], reason EvictReason) {
+	c.invalidateLoad(item.k)
+	c.unlink(item)
+	c.record(item, reason)
+	c.notify(item, item.v, reason)
+}
+
+// put stores v for k, expiring after ttl or the cache's ttl if it is 0.
+func (c *Cache[K, V]) put(k K, v V, ttl time.Duration) (*item[K, V], error) {
+	if ttl < 0 {
+		return nil, ErrInvalidTTL
+	}
+	old, exists := c.items.Get(k)
+	if exists && old.readOnly {
+		return nil, ErrReadOnly
+	}
+	for _, transform := range c.transforms {
+		var err error
+		if v, err = transform(v); err != nil {
+			return nil, err
+		}
+	}
+	c.stats.put()
+	c.invalidateLoad(k)
+	delete(c.failures, k)
+	if c.shadow != nil {
+		c.shadow.put(k)
 	}
-	if c.items.Len() == c.size {
-		c.evict()
+	if r, removed := c.removed[k]; removed {
+		// a newer value makes the softly removed one unrestorable.
+		c.drop(r)
 	}
+	if exists {
+		if c.live(old, time.Now()) {
+			c.stats.overwrites.Add(1)
+		}
+		c.update(old, v, ttl)
+		return old, nil
+	}
+	now := time.Now()
 	item := &item[K, V]{
-		v:      v,
-		k:      k,
-		expire: time.Now().Add(c.ttl),
+		v:         v,
+		k:         k,
+		ttl:       ttl,
+		expire:    c.expiresAt(now, ttl),
+		refreshed: now,
+		created:   now,
 	}
 	c.add(item)
+	return item, nil
+}
+
+// Put stores v for k. It returns ErrReadOnly if k was put with PutReadOnly, or the error of a
+// transform set with WithTransform, in which case nothing is stored.
+func (c *Cache[K, V]) Put(k K, v V) error {
+	c.lock()
+	defer c.mu.Unlock()
+	_, err := c.put(k, v, 0)
+	return err
+}
+
+// PutWithTTL is like Put but the entry expires ttl after it was last accessed, instead of the
+// cache's ttl. A ttl of 0 uses the cache's ttl and NoExpiry never expires the entry. It returns
+// ErrInvalidTTL for negative TTLs.
+func (c *Cache[K, V]) PutWithTTL(k K, v V, ttl time.Duration) error {
+	c.lock()
+	defer c.mu.Unlock()
+	_, err := c.put(k, v, ttl)
+	return err
+}
+
+// PutReadOnly is like Put but also protects the entry: later Puts to k fail with ErrReadOnly
+// until it is removed or evicted.
+func (c *Cache[K, V]) PutReadOnly(k K, v V) error {
+	c.lock()
+	defer c.mu.Unlock()
+	item, err := c.put(k, v, 0)
+	if err != nil {
+		return err
+	}
+	item.readOnly = true
+	return nil
+}
+
+// PutWithTag is like Put but also attaches an opaque tag to the entry. The tag is kept when the
+// entry is later replaced by Put, and is reported by Tag, RecentlyEvicted and the callback set by
+// WithEvictionCallback. onEvicted, which only takes the value, can't see it.
+func (c *Cache[K, V]) PutWithTag(k K, v V, tag any) error {
+	c.lock()
+	defer c.mu.Unlock()
+	item, err := c.put(k, v, 0)
+	if err != nil {
+		return err
+	}
+	item.tag = tag
+	return nil
+}
+
+// Tag returns the tag attached to k by PutWithTag, without refreshing the entry.
+func (c *Cache[K, V]) Tag(k K) (any, bool) {
+	c.lock()
+	defer c.mu.Unlock()
+	item, exists := c.items.Get(k)
+	if !exists {
+		return nil, false
+	}
+	return item.tag, true
+}
+
+// get looks up k, refreshing it on a hit.
+func (c *Cache[K, V]) get(k K) (*item[K, V], bool) {
+	c.shadowLookup(k)
+	if c.bypassed() {
+		c.miss()
+		return nil, false
+	}
+	item, exists := c.items.Get(k)
+	if !exists {
+		c.miss()
+		return nil, false
+	}
+	if !c.live(item, time.Now()) || (c.validator != nil && !c.validator(item.k, item.v)) {
+		c.miss()
+		c.delete(item, Expired)
+		return nil, false
+	}
+	c.hit()
+	item.read = true
+	c.touch(item)
+	return item, true
+}
+
+// touch refreshes item on access, unless the cache is FIFO or item reached its max extensions, in
+// which case it keeps its expiration and only becomes the most recently used outside FIFO.
+func (c *Cache[K, V]) touch(item *item[K, V]) {
+	if c.fifo {
+		return
+	}
+	if c.extensions == 0 || item.extended < c.extensions {
+		item.extended++
+		c.refresh(item)
+	} else {
+		c.recency.moveToFront(item)
+	}
 }
 
+// Get returns a copy of the value stored for k and refreshes its expiration. Expired entries are
+// reported as missing and removed, calling onEvicted.
 func (c *Cache[K, V]) Get(k K) (V, bool) {
-	c.mu.Lock()
+	c.lock()
 	defer c.mu.Unlock()
-	item, exists := c.items.Item(k)
+	item, exists := c.get(k)
+	if !exists {
+		var v V
+		return v, false
+	}
+	return item.v, true
+}
+
+// GetWithMaxStale is like Get but also returns entries that expired at most tolerance ago. Such
+// stale entries are not refreshed, but are still checked by WithValidator and subject to
+// SetBypassProbability.
+func (c *Cache[K, V]) GetWithMaxStale(k K, tolerance time.Duration) (V, bool) {
+	c.lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if item, exists := c.items.Get(k); exists && !now.Before(item.expire) {
+		c.shadowLookup(k)
+		var v V
+		if c.bypassed() {
+			c.miss()
+			return v, false
+		}
+		if c.validator != nil && !c.validator(item.k, item.v) {
+			c.miss()
+			c.delete(item, Expired)
+			return v, false
+		}
+		if now.Sub(item.expire) <= tolerance && !c.lifetimeExceeded(item, now) {
+			c.hit()
+			item.read = true
+			return item.v, true
+		}
+		c.miss()
+		return v, false
+	}
+	item, exists := c.get(k)
 	if !exists {
 		var v V
 		return v, false
 	}
-	c.refresh(item)
 	return item.v, true
 }
 
+// GetWithExpiration is like Get but also returns when the entry expires after being refreshed.
+func (c *Cache[K, V]) GetWithExpiration(k K) (V, time.Time, bool) {
+	c.lock()
+	defer c.mu.Unlock()
+	item, exists := c.get(k)
+	if !exists {
+		var v V
+		return v, time.Time{}, false
+	}
+	return item.v, item.expire, true
+}
+
+// TTL returns how long until k expires, without refreshing it.
+func (c *Cache[K, V]) TTL(k K) (time.Duration, bool) {
+	c.lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	item, exists := c.items.Get(k)
+	if !exists || !c.live(item, now) {
+		return 0, false
+	}
+	return item.expire.Sub(now), true
+}
+
+// Update calls fn with a pointer to the value stored for k, so it can be modified in place, and
+// refreshes the entry like Get. fn runs with the cache locked: it must not use the cache or keep
+// the pointer. The new value doesn't go through WithTransform. Update reports whether fn was
+// called, which it isn't if k is missing, expired or was put with PutReadOnly.
+func (c *Cache[K, V]) Update(k K, fn func(v *V)) bool {
+	c.lock()
+	defer c.mu.Unlock()
+	if item, exists := c.items.Get(k); exists && item.readOnly {
+		return false
+	}
+	item, exists := c.get(k)
+	if !exists {
+		return false
+	}
+	fn(&item.v)
+	return true
+}
+
+// Touch refreshes k's expiration as Get would, without returning its value, and reports whether
+// k is in the cache and has not expired. Like Get, it doesn't extend entries of a WithFIFO cache
+// or past the limit set by WithMaxExtensions.
+func (c *Cache[K, V]) Touch(k K) bool {
+	c.lock()
+	defer c.mu.Unlock()
+	item, exists := c.items.Get(k)
+	if !exists || !c.live(item, time.Now()) {
+		return false
+	}
+	c.touch(item)
+	return true
+}
+
+// Extend pushes k's expiration back by d, and reports whether it did: k must be in the cache, not
+// expired, and below the limit set by WithMaxExtensions, which Extend counts against like Get.
+func (c *Cache[K, V]) Extend(k K, d time.Duration) bool {
+	c.lock()
+	defer c.mu.Unlock()
+	item, exists := c.items.Get(k)
+	if !exists || !c.live(item, time.Now()) {
+		return false
+	}
+	if c.extensions > 0 && item.extended >= c.extensions {
+		return false
+	}
+	item.extended++
+	item.expire = item.expire.Add(d)
+	c.items.Fix(k)
+	return true
+}
+
+// Expire makes k expire now, without removing it: it stays in the cache, first in line for
+// eviction, but is reported as missing from then on. onEvicted is not called until a Get finds it
+// expired or it is evicted. Expire reports whether k was in the cache.
+func (c *Cache[K, V]) Expire(k K) bool {
+	c.lock()
+	defer c.mu.Unlock()
+	item, exists := c.items.Get(k)
+	if !exists {
+		return false
+	}
+	item.expire = time.Now()
+	c.items.Fix(k)
+	c.recency.moveToBack(item)
+	return true
+}
+
+// Peek returns the value stored for k without refreshing it. Expired entries are reported as missing.
+func (c *Cache[K, V]) Peek(k K) (V, bool) {
+	c.lock()
+	defer c.mu.Unlock()
+	item, exists := c.items.Get(k)
+	if !exists || !c.live(item, time.Now()) {
+		var v V
+		return v, false
+	}
+	item.read = true
+	return item.v, true
+}
+
+// GetOldest returns the least recently used entry, without refreshing it. Unless it is pinned or
+// another entry has expired, it is the next to be evicted.
+func (c *Cache[K, V]) GetOldest() (K, V, bool) {
+	c.lock()
+	defer c.mu.Unlock()
+	return entry(c.recency.back())
+}
+
+// GetNewest returns the most recently used entry, without refreshing it.
+func (c *Cache[K, V]) GetNewest() (K, V, bool) {
+	c.lock()
+	defer c.mu.Unlock()
+	return entry(c.recency.front())
+}
+
+// entry returns item's key and value, and whether item is not nil.
+func entry[K any, V any](item *item[K, V]) (K, V, bool) {
+	if item == nil {
+		var k K
+		var v V
+		return k, v, false
+	}
+	return item.k, item.v, true
+}
+
+// Contains reports whether k is in the cache and has not expired, without refreshing it.
+func (c *Cache[K, V]) Contains(k K) bool {
+	c.lock()
+	defer c.mu.Unlock()
+	item, exists := c.items.Get(k)
+	return exists && c.live(item, time.Now())
+}
+
+// moveMu serializes Moves, so that holding both caches' locks can't deadlock.
+var moveMu sync.Mutex
+
+// Move moves the entry for k from c to dst, keeping its expiration and tag, and reports whether k
+// was moved. Expired entries are not moved, as if they were missing. It replaces any entry dst has
+// for k, unless that entry is read-only. onEvicted is not called for the moved value unless dst
+// has no room for it.
+func (c *Cache[K, V]) Move(dst *Cache[K, V], k K) bool {
+	if dst == c {
+		return c.Contains(k)
+	}
+	moveMu.Lock()
+	defer moveMu.Unlock()
+	c.lock()
+	defer c.mu.Unlock()
+	item, exists := c.items.Get(k)
+	if !exists || !c.live(item, time.Now()) {
+		return false
+	}
+	dst.lock()
+	defer dst.mu.Unlock()
+	if old, exists := dst.items.Get(k); exists {
+		if old.readOnly {
+			return false
+		}
+		dst.unlink(old)
+		dst.replaced(old, old.v)
+	}
+	c.invalidateLoad(k)
+	c.unlink(item)
+	dst.invalidateLoad(k)
+	dst.add(item)
+	return true
+}
+
 func (c *Cache[K, V]) Remove(k K) {
-	c.mu.Lock()
+	c.lock()
 	defer c.mu.Unlock()
-	item, exists := c.items.Item(k)
+	delete(c.failures, k)
+	if c.shadow != nil {
+		c.shadow.remove(k)
+	}
+	item, exists := c.items.Get(k)
 	if !exists {
 		return
 	}
-	c.delete(item)
+	c.delete(item, Removed)
+}
+
+// RemoveAndGet removes k and returns its value, handing it over to the caller: onEvicted is not
+// called for it, only the callback set by WithEvictionCallback.
+func (c *Cache[K, V]) RemoveAndGet(k K) (V, bool) {
+	c.lock()
+	defer c.mu.Unlock()
+	item, exists := c.items.Get(k)
+	if !exists {
+		var v V
+		return v, false
+	}
+	c.unlink(item)
+	c.invalidateLoad(k)
+	if c.shadow != nil {
+		c.shadow.remove(k)
+	}
+	c.handOver(item)
+	return item.v, true
+}
+
+// RemoveOldest removes the entry the cache would evict next, skipping pinned entries, and returns
+// it. Like RemoveAndGet, it hands the value over to the caller without calling onEvicted.
+func (c *Cache[K, V]) RemoveOldest() (K, V, bool) {
+	c.lock()
+	defer c.mu.Unlock()
+	item := c.victim()
+	if item == nil {
+		var k K
+		var v V
+		return k, v, false
+	}
+	c.unlink(item)
+	c.handOver(item)
+	return item.k, item.v, true
+}
+
+// handOver accounts for item being removed and its value handed over to the caller, which
+// onEvicted is not called for.
+func (c *Cache[K, V]) handOver(item *item[K, V]) {
+	item.read = true
+	c.record(item, Removed)
+	if c.onEvict != nil {
+		c.onEvict(item.k, item.v, Removed, item.tag)
+	}
+}
+
+// ExpireMany removes the entries for keys, calling onEvicted for each, and returns how many were
+// in the cache.
+func (c *Cache[K, V]) ExpireMany(keys []K) int {
+	c.lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, k := range keys {
+		if item, exists := c.items.Get(k); exists {
+			c.delete(item, Expired)
+			n++
+		}
+	}
+	c.shrinkIfSparse(n)
+	return n
+}
+
+// ExpireWhere removes every entry for which pred returns true, calling onEvicted for each, and
+// returns how many were removed. pred must not use the cache.
+func (c *Cache[K, V]) ExpireWhere(pred func(K, V) bool) int {
+	c.lock()
+	defer c.mu.Unlock()
+	var expired []*item[K, V]
+	c.items.Range(func(k K, item *item[K, V]) bool {
+		if pred(k, item.v) {
+			expired = append(expired, item)
+		}
+		return true
+	})
+	for _, item := range expired {
+		c.delete(item, Expired)
+	}
+	c.shrinkIfSparse(len(expired))
+	return len(expired)
+}
+
+// Keys returns a snapshot of the cache's keys, in the order they would be evicted.
+func (c *Cache[K, V]) Keys() []K {
+	c.lock()
+	defer c.mu.Unlock()
+	items := c.inEvictionOrder()
+	keys := make([]K, len(items))
+	for i, item := range items {
+		keys[i] = item.k
+	}
+	return keys
+}
+
+// Values returns a snapshot of the cache's values, in the same order as Keys.
+func (c *Cache[K, V]) Values() []V {
+	c.lock()
+	defer c.mu.Unlock()
+	items := c.inEvictionOrder()
+	values := make([]V, len(items))
+	for i, item := range items {
+		values[i] = item.v
+	}
+	return values
+}
+
+// inEvictionOrder returns the cache's items, least recently used first.
+func (c *Cache[K, V]) inEvictionOrder() []*item[K, V] {
+	items := make([]*item[K, V], 0, c.items.Len())
+	for item := c.recency.back(); item != nil; item = c.recency.newer(item) {
+		items = append(items, item)
+	}
+	return items
+}
+
+// Purge removes every entry, including those removed by RemoveSoft, calling onEvicted for each.
+func (c *Cache[K, V]) Purge() {
+	c.lock()
+	defer c.mu.Unlock()
+	c.items.Range(func(k K, item *item[K, V]) bool {
+		c.record(item, Removed)
+		c.notify(item, item.v, Removed)
+		return true
+	})
+	for _, r := range c.removed {
+		c.drop(r)
+	}
+	c.reset(0)
+	c.invalidateLoads()
+}
+
+// Entry is a key and value to store in a cache, see ReplaceAll.
+type Entry[K comparable, V any] struct {
+	Key   K
+	Value V
+	TTL   time.Duration // 0 for the cache's ttl
+}
+
+// ReplaceAll atomically replaces the cache's contents with entries, even read-only ones: lookups
+// see either the old or the new contents, never a mix. Entries go through WithTransform like a
+// Put. If a key appears more than once, its last entry wins. If there are more entries than the
+// cache holds, only the last ones are stored; the others are dropped without calling onEvicted.
+// Displaced values, including those removed by RemoveSoft, are reported as if removed, or as
+// replaced if entries has a new value for their key. ReplaceAll leaves the cache unchanged and
+// returns ErrInvalidTTL if an entry has a negative TTL, or the error of a failing transform.
+func (c *Cache[K, V]) ReplaceAll(entries []Entry[K, V]) error {
+	// prepare the new items before taking the lock, last entries first to skip duplicates.
+	items := make([]*item[K, V], 0, len(entries))
+	seen := make(map[K]bool, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.TTL < 0 {
+			return ErrInvalidTTL
+		}
+		if seen[e.Key] {
+			continue
+		}
+		seen[e.Key] = true
+		v := e.Value
+		for _, transform := range c.transforms {
+			var err error
+			if v, err = transform(v); err != nil {
+				return err
+			}
+		}
+		items = append(items, &item[K, V]{k: e.Key, v: v, ttl: e.TTL})
+	}
+	c.lock()
+	defer c.mu.Unlock()
+	if _, high := c.watermarks(); len(items) > high {
+		items = items[:high]
+	}
+	displaced := c.inEvictionOrder()
+	c.reset(len(items))
+	c.invalidateLoads()
+	now := time.Now()
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		item.expire = c.expiresAt(now, item.ttl)
+		item.refreshed = now
+		item.created = now
+		c.stats.put()
+		c.add(item)
+	}
+	for _, item := range displaced {
+		if _, exists := c.items.Get(item.k); exists {
+			c.replaced(item, item.v)
+			continue
+		}
+		c.record(item, Removed)
+		c.notify(item, item.v, Removed)
+	}
+	for _, r := range c.removed {
+		c.drop(r)
+	}
+	return nil
+}
+
+// Clear removes every entry without calling onEvicted.
+func (c *Cache[K, V]) Clear() {
+	c.lock()
+	defer c.mu.Unlock()
+	c.reset(0)
+	c.invalidateLoads()
+	c.removed = nil
+}
+
+// RemoveExpired removes every expired entry, calling onEvicted for each, and returns how many
+// were removed.
+func (c *Cache[K, V]) RemoveExpired() int {
+	return c.removeExpired(0)
+}
+
+// removeExpired removes up to limit expired entries, or all of them if limit is 0, first to
+// expire first, and returns how many were removed.
+func (c *Cache[K, V]) removeExpired(limit int) int {
+	c.lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	defer func() {
+		c.stats.sweeps.Add(1)
+		c.stats.sweepTime.Add(uint64(time.Since(now)))
+	}()
+	n := 0
+	for limit == 0 || n < limit {
+		_, item, ok := c.items.Peek()
+		if !ok || now.Before(item.expire) {
+			break
+		}
+		c.delete(item, Expired)
+		n++
+	}
+	c.shrinkIfSparse(n)
+	return n
+}
+
+// janitor removes up to limit expired entries every interval until the cache is closed.
+func (c *Cache[K, V]) janitor(interval time.Duration, limit int) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			start := time.Now()
+			n := c.removeExpired(limit)
+			if c.debugLog != nil {
+				c.debugLog("lru: janitor run", "removed", n, "duration", time.Since(start))
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Close stops the goroutines started by WithJanitor and WithAnomalyDetector. The cache remains
+// usable. Close is a no-op for caches without them.
+func (c *Cache[K, V]) Close() error {
+	if c.done != nil {
+		c.closeOnce.Do(func() { close(c.done) })
+	}
+	return nil
+}
+
+// Len returns the number of entries in the cache, including expired entries not yet removed.
+func (c *Cache[K, V]) Len() int {
+	c.lock()
+	defer c.mu.Unlock()
+	return c.items.Len()
+}
+
+// Resize changes the maximum number of entries the cache holds, evicting entries as needed when
+// shrinking, and returns the number of entries evicted. Pinned entries are kept even if the cache
+// ends up over its new size.
+func (c *Cache[K, V]) Resize(size int) int {
+	if size < 0 {
+		panic("Cache: cannot have negative size")
+	}
+	c.lock()
+	defer c.mu.Unlock()
+	old := c.size
+	c.size = size
+	evicted := 0
+	for c.items.Len() > size && c.evict() {
+		evicted++
+	}
+	if evicted > 0 {
+		c.items.Shrink()
+	}
+	if c.debugLog != nil {
+		c.debugLog("lru: resized", "old", old, "new", size, "evicted", evicted)
+	}
+	return evicted
+}
+
+// SetTTL changes the cache's ttl, used by entries not put with their own. Existing entries keep
+// their expiration until they are next refreshed, unless rebase is true, in which case it is
+// recomputed as if the new ttl had applied when they were last refreshed. Like TryNew, it returns
+// ErrInvalidTTL unless ttl is positive.
+func (c *Cache[K, V]) SetTTL(ttl time.Duration, rebase bool) error {
+	if ttl <= 0 {
+		return ErrInvalidTTL
+	}
+	c.lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+	if !rebase {
+		return nil
+	}
+	var rebased []*item[K, V]
+	c.items.Range(func(_ K, item *item[K, V]) bool {
+		if item.ttl == 0 {
+			rebased = append(rebased, item)
+		}
+		return true
+	})
+	for _, item := range rebased {
+		item.expire = c.expiresAt(item.refreshed, 0)
+		c.items.Fix(item.k)
+	}
+	return nil
+}
+
+// Cap returns the maximum number of entries the cache holds.
+func (c *Cache[K, V]) Cap() int {
+	c.lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// RecentlyEvicted returns the most recent evictions, oldest first.
+// It is empty unless the cache was created with WithRecentlyEvicted.
+func (c *Cache[K, V]) RecentlyEvicted() []Eviction[K] {
+	c.lock()
+	defer c.mu.Unlock()
+	return c.evictions.entries()
+}
+
+// ShrinkToFit releases memory held by the cache's internal structures beyond what the current
+// entries need, e.g. after removing most of them. The structures grow again as entries are added.
+// ExpireMany, ExpireWhere, RemoveExpired and RemoveRange already do this when they leave less than
+// a quarter of the room in use, and Purge and Clear start over with empty structures.
+func (c *Cache[K, V]) ShrinkToFit() {
+	c.lock()
+	defer c.mu.Unlock()
+	c.items.Shrink()
+}
+
+// ExpirationHistogram counts entries by how soon they expire. bounds must be ascending; counts[i]
+// is the number of entries expiring after bounds[i-1] and no later than bounds[i] from now, with
+// already expired entries counted in counts[0]. Entries expiring after the last bound are not counted.
+func (c *Cache[K, V]) ExpirationHistogram(bounds ...time.Duration) (counts []int) {
+	counts = make([]int, len(bounds))
+	c.lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	c.items.Range(func(_ K, item *item[K, V]) bool {
+		d := item.expire.Sub(now)
+		if i := sort.Search(len(bounds), func(i int) bool { return d <= bounds[i] }); i < len(bounds) {
+			counts[i]++
+		}
+		return true
+	})
+	return counts
+}
+
+// AgeQuantiles returns, for each quantile q in qs between 0 and 1, the time since Put that a
+// fraction q of the entries have not exceeded. It sorts the ages of every entry, so it is meant for
+// periodic reporting rather than hot paths. All ages are 0 if the cache is empty.
+func (c *Cache[K, V]) AgeQuantiles(qs ...float64) []time.Duration {
+	c.lock()
+	now := time.Now()
+	ages := make([]time.Duration, 0, c.items.Len())
+	c.items.Range(func(_ K, item *item[K, V]) bool {
+		ages = append(ages, now.Sub(item.created))
+		return true
+	})
+	c.mu.Unlock()
+	sort.Slice(ages, func(i, j int) bool { return ages[i] < ages[j] })
+	quantiles := make([]time.Duration, len(qs))
+	if len(ages) == 0 {
+		return quantiles
+	}
+	for i, q := range qs {
+		j := int(q * float64(len(ages)-1))
+		if j < 0 {
+			j = 0
+		} else if j >= len(ages) {
+			j = len(ages) - 1
+		}
+		quantiles[i] = ages[j]
+	}
+	return quantiles
+}
+
+// KeysPage returns up to limit keys starting at cursor, and the cursor for the next page. Start
+// with a cursor of 0; a returned cursor of 0 means there are no more keys. A limit of 0 or less
+// means no limit. Entries added, removed or reordered between calls may be skipped or returned
+// twice.
+func (c *Cache[K, V]) KeysPage(cursor, limit int) (keys []K, next int) {
+	c.lock()
+	defer c.mu.Unlock()
+	n := c.items.Len()
+	if cursor < 0 || cursor >= n {
+		return nil, 0
+	}
+	end := n
+	if limit > 0 && limit < n-cursor {
+		end = cursor + limit
+	}
+	keys = make([]K, 0, end-cursor)
+	for i := cursor; i < end; i++ {
+		k, _ := c.items.At(i)
+		keys = append(keys, k)
+	}
+	if end == n {
+		end = 0
+	}
+	return keys, end
+}
+
+// SampleKeys returns up to n keys chosen uniformly at random without replacement, without
+// enumerating the whole cache. Expired entries not yet removed may be included.
+func (c *Cache[K, V]) SampleKeys(n int) []K {
+	c.lock()
+	defer c.mu.Unlock()
+	size := c.items.Len()
+	if n > size {
+		n = size
+	}
+	if n <= 0 {
+		return nil
+	}
+	// Floyd's algorithm picks n distinct indices with n random numbers.
+	keys := make([]K, 0, n)
+	chosen := make(map[int]struct{}, n)
+	for j := size - n; j < size; j++ {
+		i := rand.Intn(j + 1)
+		if _, dup := chosen[i]; dup {
+			i = j
+		}
+		chosen[i] = struct{}{}
+		k, _ := c.items.At(i)
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// SetBypassProbability makes each Get miss with probability p, regardless of the cache's contents,
+// so that a share of traffic goes to the backend. It can be changed at any time; 0 turns bypassing off.
+func (c *Cache[K, V]) SetBypassProbability(p float64) {
+	c.bypass.Store(math.Float64bits(p))
+}
+
+func (c *Cache[K, V]) bypassed() bool {
+	p := math.Float64frombits(c.bypass.Load())
+	return p > 0 && rand.Float64() < p
+}
+
+// hit counts a lookup that found an entry.
+func (c *Cache[K, V]) hit() {
+	c.stats.hit()
+	c.checkHitRatio()
+}
+
+// miss counts a lookup that found no entry.
+func (c *Cache[K, V]) miss() {
+	c.stats.miss()
+	c.checkHitRatio()
+}
+
+// checkHitRatio warms the cache up if it was created with WithWarmupHitRatio and its hit ratio
+// has reached the one given.
+func (c *Cache[K, V]) checkHitRatio() {
+	if c.warmRatio <= 0 {
+		return
+	}
+	hits := c.stats.hits.Load()
+	lookups := hits + c.stats.misses.Load()
+	if lookups > 0 && lookups >= c.warmLookups && float64(hits) >= c.warmRatio*float64(lookups) {
+		c.warmUp()
+	}
+}
+
+// warmUp marks the cache as warmed up, if it isn't already.
+func (c *Cache[K, V]) warmUp() {
+	select {
+	case <-c.warm:
+	default:
+		close(c.warm)
+	}
+}
+
+// WarmedUp reports whether the cache has held the number of entries given to WithWarmup, or
+// reached the hit ratio given to WithWarmupHitRatio. A cache stays warm once it has been, even if
+// entries are removed or its hit ratio drops later.
+func (c *Cache[K, V]) WarmedUp() bool {
+	select {
+	case <-c.warm:
+		return true
+	default:
+		return false
+	}
+}
+
+// WaitWarm blocks until the cache is warmed up or ctx is done, in which case it returns ctx.Err().
+func (c *Cache[K, V]) WaitWarm(ctx context.Context) error {
+	select {
+	case <-c.warm:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Pin exempts k from eviction to make room for other entries until it is unpinned, and reports
+// whether k is in the cache. If every entry is pinned, Put evicts the value it was given instead.
+func (c *Cache[K, V]) Pin(k K) bool {
+	return c.pin(k, forever)
+}
+
+// PinFor is like Pin, but the pin is released automatically after d.
+func (c *Cache[K, V]) PinFor(k K, d time.Duration) bool {
+	return c.pin(k, time.Now().Add(d))
+}
+
+// Unpin releases a pin set by Pin or PinFor.
+func (c *Cache[K, V]) Unpin(k K) bool {
+	return c.pin(k, time.Time{})
+}
+
+func (c *Cache[K, V]) pin(k K, until time.Time) bool {
+	c.lock()
+	defer c.mu.Unlock()
+	item, exists := c.items.Get(k)
+	if exists {
+		item.pinUntil = until
+	}
+	return exists
 }
@@ -1,8 +1,15 @@
 package lru
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
 	"strconv"
+	"strings"
 	"testing"
+	"text/tabwriter"
 	"time"
 )
 
@@ -11,11 +18,11 @@ func TestCache(t *testing.T) {
 	c.Put("A", 1)
 	time.Sleep(5 * time.Millisecond)
 	c.Put("B", 2)
-	if l := c.items.Len(); l != 2 {
+	if l := c.Len(); l != 2 {
 		t.Fatalf("items size %d is not 2", l)
 	}
-	if l := c.items.Len(); l != 2 {
-		t.Fatalf("tlru size %d is not 2", l)
+	if c := c.Cap(); c != 2 {
+		t.Fatalf("capacity %d is not 2", c)
 	}
 	// LRU: [A, B]
 	// Put C evicts A
@@ -51,6 +58,1070 @@ func TestCache(t *testing.T) {
 	})
 }
 
+func TestPeek(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {})
+	c.Put("A", 1)
+	time.Sleep(time.Millisecond)
+	c.Put("B", 2)
+	if v, ok := c.Peek("A"); !ok || v != 1 {
+		t.Fatalf("'A' value %d is not 1", v)
+	}
+	// Peek did not refresh A, so it is still evicted first.
+	c.Put("C", 3)
+	if _, ok := c.Peek("A"); ok {
+		t.Fatal("'A' should not be in the cache anymore!")
+	}
+}
+
+func TestContains(t *testing.T) {
+	c := New[string](2, time.Millisecond, func(i int) {})
+	c.Put("a", 1)
+	if !c.Contains("a") {
+		t.Fatal("'a' should be in the cache")
+	}
+	if c.Contains("b") {
+		t.Fatal("'b' should not be in the cache")
+	}
+	time.Sleep(2 * time.Millisecond)
+	if c.Contains("a") {
+		t.Fatal("'a' should have expired")
+	}
+}
+
+func TestReadOnlyEntry(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {})
+	if err := c.PutReadOnly("a", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Put("a", 2); err != ErrReadOnly {
+		t.Fatalf("Put returned %v, want ErrReadOnly", err)
+	}
+	if v, _ := c.Get("a"); v != 1 {
+		t.Fatalf("'a' value %d is not 1", v)
+	}
+	c.Remove("a")
+	if err := c.Put("a", 2); err != nil {
+		t.Fatalf("Put after Remove returned %v", err)
+	}
+}
+
+func TestMove(t *testing.T) {
+	evicted := 0
+	l1 := New[string](1, time.Hour, func(i int) { evicted++ })
+	l2 := New[string](1, time.Minute, func(i int) { evicted++ })
+	l1.PutWithTag("a", 1, "tag")
+	item, _ := l1.items.Get("a")
+	expire := item.expire
+	if !l1.Move(l2, "a") {
+		t.Fatal("'a' should have been moved")
+	}
+	if _, ok := l1.Peek("a"); ok {
+		t.Fatal("'a' should not be in the source anymore")
+	}
+	item, ok := l2.items.Get("a")
+	if !ok || item.v != 1 || item.tag != "tag" || !item.expire.Equal(expire) {
+		t.Fatalf("moved entry %+v lost its value or metadata", item)
+	}
+	if evicted != 0 {
+		t.Fatalf("onEvicted called %d times for a move", evicted)
+	}
+	if l1.Move(l2, "a") {
+		t.Fatal("moving a missing key should report false")
+	}
+}
+
+func TestExpireMany(t *testing.T) {
+	evicted := 0
+	c := New[string](4, time.Hour, func(i int) { evicted++ })
+	for i := 0; i < 4; i++ {
+		c.Put(strconv.Itoa(i), i)
+	}
+	if n := c.ExpireMany([]string{"0", "1", "x"}); n != 2 {
+		t.Fatalf("expired %d entries, want 2", n)
+	}
+	if n := c.ExpireWhere(func(k string, v int) bool { return v == 3 }); n != 1 {
+		t.Fatalf("expired %d entries, want 1", n)
+	}
+	if l := c.Len(); l != 1 || evicted != 3 {
+		t.Fatalf("%d entries left after %d evictions, want 1 after 3", l, evicted)
+	}
+}
+
+func TestKeysValues(t *testing.T) {
+	c := New[string](3, time.Hour, func(i int) {})
+	c.Put("a", 1)
+	time.Sleep(time.Millisecond)
+	c.Put("b", 2)
+	time.Sleep(time.Millisecond)
+	c.Put("c", 3)
+	c.Get("a")
+	if keys := fmt.Sprint(c.Keys()); keys != "[b c a]" {
+		t.Fatalf("keys %s are not [b c a]", keys)
+	}
+	if values := fmt.Sprint(c.Values()); values != "[2 3 1]" {
+		t.Fatalf("values %s are not [2 3 1]", values)
+	}
+}
+
+func TestPutWithTTL(t *testing.T) {
+	c := New[string](3, time.Millisecond, func(i int) {})
+	if err := c.PutWithTTL("a", 1, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.PutWithTTL("b", 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.PutWithTTL("c", 3, NoExpiry); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.PutWithTTL("d", 4, -time.Second); err != ErrInvalidTTL {
+		t.Fatalf("negative ttl returned %v, want ErrInvalidTTL", err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	c.Get("a")
+	if !c.Contains("a") || c.Contains("b") || !c.Contains("c") {
+		t.Fatalf("want 'a' and 'c' live and 'b' expired, got keys %v", c.Keys())
+	}
+}
+
+func TestPurge(t *testing.T) {
+	evicted := 0
+	c := New[string](2, time.Hour, func(i int) { evicted++ })
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Purge()
+	if l := c.Len(); l != 0 || evicted != 2 {
+		t.Fatalf("%d entries left after %d evictions, want 0 after 2", l, evicted)
+	}
+	c.Put("a", 1)
+	c.Clear()
+	if l := c.Len(); l != 0 || evicted != 2 {
+		t.Fatalf("%d entries left after %d evictions, want 0 after 2", l, evicted)
+	}
+}
+
+func TestRemoveAndGet(t *testing.T) {
+	evicted := 0
+	c := New[string](1, time.Hour, func(i int) { evicted++ })
+	c.Put("a", 1)
+	if v, ok := c.RemoveAndGet("a"); !ok || v != 1 {
+		t.Fatalf("RemoveAndGet returned %d, %v", v, ok)
+	}
+	if _, ok := c.RemoveAndGet("a"); ok {
+		t.Fatal("'a' should not be in the cache anymore!")
+	}
+	if evicted != 0 {
+		t.Fatalf("onEvicted called %d times", evicted)
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	evicted := 0
+	spilled := make(map[string]int)
+	c := New[string](1, time.Hour, func(i int) { evicted++ }, WithOverflow(func(k string, v int) bool {
+		if v%2 == 0 {
+			return false
+		}
+		spilled[k] = v
+		return true
+	}))
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+	if len(spilled) != 1 || spilled["a"] != 1 {
+		t.Fatalf("spilled %v, want only a", spilled)
+	}
+	if evicted != 1 {
+		t.Fatalf("onEvicted called %d times, want 1", evicted)
+	}
+}
+
+func TestResize(t *testing.T) {
+	evicted := 0
+	c := New[string](4, time.Hour, func(i int) { evicted++ })
+	for i := 0; i < 4; i++ {
+		c.Put(strconv.Itoa(i), i)
+	}
+	if n := c.Resize(2); n != 2 || evicted != 2 {
+		t.Fatalf("Resize evicted %d, onEvicted called %d times, want 2", n, evicted)
+	}
+	if c.Cap() != 2 || c.Len() != 2 {
+		t.Fatalf("cache holds %d of %d, want 2 of 2", c.Len(), c.Cap())
+	}
+	if n := c.Resize(3); n != 0 {
+		t.Fatalf("growing evicted %d", n)
+	}
+	c.Put("a", 1)
+	if l := c.Len(); l != 3 {
+		t.Fatalf("items size %d is not 3", l)
+	}
+}
+
+func TestSetTTL(t *testing.T) {
+	c := New[string](3, time.Hour, func(i int) {})
+	c.Put("a", 1)
+	c.PutWithTTL("b", 2, time.Hour)
+	c.SetTTL(time.Millisecond, false)
+	c.Put("c", 3)
+	time.Sleep(2 * time.Millisecond)
+	if !c.Contains("a") || c.Contains("c") {
+		t.Fatal("only new entries should use the new ttl without rebase")
+	}
+	c.SetTTL(time.Millisecond, true)
+	if c.Contains("a") || !c.Contains("b") {
+		t.Fatal("rebase should only apply the new ttl to entries without their own")
+	}
+	if err := c.SetTTL(-1, false); err != ErrInvalidTTL {
+		t.Fatalf("negative ttl returned %v, want ErrInvalidTTL", err)
+	}
+	if err := c.SetTTL(0, false); err != ErrInvalidTTL {
+		t.Fatalf("zero ttl returned %v, want ErrInvalidTTL", err)
+	}
+}
+
+func TestExpiration(t *testing.T) {
+	c := New[string](1, time.Hour, func(i int) {})
+	c.Put("a", 1)
+	before := time.Now()
+	v, expire, ok := c.GetWithExpiration("a")
+	if !ok || v != 1 || expire.Before(before.Add(time.Hour)) {
+		t.Fatalf("GetWithExpiration returned %d, %v, %v", v, expire, ok)
+	}
+	if ttl, ok := c.TTL("a"); !ok || ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Fatalf("TTL returned %v, %v", ttl, ok)
+	}
+	if _, ok := c.TTL("b"); ok {
+		t.Fatal("'b' should not have a ttl")
+	}
+}
+
+func TestTransform(t *testing.T) {
+	errNegative := errors.New("negative")
+	c := New[string](2, time.Hour, func(i int) {},
+		WithTransform(func(v int) (int, error) {
+			if v < 0 {
+				return 0, errNegative
+			}
+			return v * 2, nil
+		}),
+		WithTransform(func(v int) (int, error) { return v + 1, nil }),
+	)
+	c.Put("a", 1)
+	if v, _ := c.Get("a"); v != 3 {
+		t.Fatalf("'a' value %d is not 3", v)
+	}
+	if err := c.Put("a", -1); err != errNegative {
+		t.Fatalf("Put returned %v, want the transform's error", err)
+	}
+	if v, _ := c.Get("a"); v != 3 {
+		t.Fatalf("'a' value %d changed by a failed Put", v)
+	}
+}
+
+func TestGetWithMaxStale(t *testing.T) {
+	c := New[string](1, time.Millisecond, func(i int) {})
+	c.Put("a", 1)
+	time.Sleep(2 * time.Millisecond)
+	if v, ok := c.GetWithMaxStale("a", time.Hour); !ok || v != 1 {
+		t.Fatalf("stale 'a' value %d is not 1", v)
+	}
+	if _, ok := c.GetWithMaxStale("a", 0); ok {
+		t.Fatal("'a' is staler than tolerated")
+	}
+}
+
+func TestGetWithMaxStaleValidator(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {}, WithValidator(func(k string, v int) bool { return false }))
+	c.Put("a", 1)
+	c.Expire("a")
+	if _, ok := c.GetWithMaxStale("a", time.Hour); ok {
+		t.Fatal("a stale entry rejected by the validator should be a miss")
+	}
+	c = New[string](2, time.Hour, func(i int) {})
+	c.Put("a", 1)
+	c.Expire("a")
+	c.SetBypassProbability(1)
+	if _, ok := c.GetWithMaxStale("a", time.Hour); ok {
+		t.Fatal("a bypassed lookup should miss stale entries too")
+	}
+}
+
+func TestTouchExtend(t *testing.T) {
+	c := New[string](2, 50*time.Millisecond, func(i int) {})
+	c.Put("a", 1)
+	c.Put("b", 2)
+	if !c.Extend("b", time.Hour) {
+		t.Fatal("'b' should have been extended")
+	}
+	for i := 0; i < 3; i++ {
+		time.Sleep(20 * time.Millisecond)
+		if !c.Touch("a") {
+			t.Fatal("'a' should have been touched")
+		}
+	}
+	if !c.Contains("a") || !c.Contains("b") {
+		t.Fatal("'a' and 'b' should not have expired")
+	}
+	if c.Touch("c") || c.Extend("c", time.Hour) {
+		t.Fatal("missing 'c' should not be touched or extended")
+	}
+}
+
+func TestExpire(t *testing.T) {
+	evicted := 0
+	c := New[string](2, time.Hour, func(i int) { evicted++ })
+	c.Put("a", 1)
+	c.Put("b", 2)
+	if !c.Expire("b") {
+		t.Fatal("'b' should have been expired")
+	}
+	if c.Contains("b") || evicted != 0 {
+		t.Fatal("'b' should be expired without calling onEvicted")
+	}
+	c.Put("c", 3)
+	if !c.Contains("a") || evicted != 1 {
+		t.Fatal("expired 'b' should have been evicted before 'a'")
+	}
+}
+
+func TestRemoveOldest(t *testing.T) {
+	c := New[string](3, time.Hour, func(i int) {})
+	c.Put("a", 1)
+	time.Sleep(time.Millisecond)
+	c.Put("b", 2)
+	c.Pin("a")
+	if k, v, ok := c.RemoveOldest(); !ok || k != "b" || v != 2 {
+		t.Fatalf("RemoveOldest returned %s: %d, %v, want b: 2", k, v, ok)
+	}
+	if _, _, ok := c.RemoveOldest(); ok {
+		t.Fatal("only pinned entries are left")
+	}
+	if !c.Contains("a") {
+		t.Fatal("pinned 'a' should not have been removed")
+	}
+}
+
+func TestGetOldestNewest(t *testing.T) {
+	c := New[string](3, time.Hour, func(i int) {})
+	if _, _, ok := c.GetOldest(); ok {
+		t.Fatal("empty cache has no oldest entry")
+	}
+	for i, k := range []string{"a", "b", "c"} {
+		c.Put(k, i)
+		time.Sleep(time.Millisecond)
+	}
+	if k, v, _ := c.GetOldest(); k != "a" || v != 0 {
+		t.Fatalf("oldest is %s: %d, want a: 0", k, v)
+	}
+	if k, v, _ := c.GetNewest(); k != "c" || v != 2 {
+		t.Fatalf("newest is %s: %d, want c: 2", k, v)
+	}
+	if k, _, _ := c.GetOldest(); k != "a" {
+		t.Fatal("GetOldest and GetNewest should not refresh entries")
+	}
+}
+
+func TestGetExpired(t *testing.T) {
+	c := New[string](2, time.Millisecond, func(i int) {}, WithRecentlyEvicted(1))
+	c.Put("a", 1)
+	time.Sleep(2 * time.Millisecond)
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expired 'a' should be a miss")
+	}
+	if got := c.RecentlyEvicted(); len(got) != 1 || got[0].Reason != Expired {
+		t.Fatalf("evictions %v, want 'a' expired", got)
+	}
+}
+
+func TestRandomEviction(t *testing.T) {
+	const size = 100
+	c := New[int](size, time.Hour, func(i int) {}, WithRandomEviction(5))
+	for i := 0; i < size; i++ {
+		c.Put(i, i)
+	}
+	evictedOldest := true
+	for i := size; i < 2*size; i++ {
+		oldest, _, _ := c.GetOldest()
+		c.Put(i, i)
+		evictedOldest = evictedOldest && !c.Contains(oldest)
+	}
+	if evictedOldest {
+		t.Fatal("eviction always picked the oldest entry")
+	}
+	if l := c.Len(); l != size {
+		t.Fatalf("items size %d is not %d", l, size)
+	}
+}
+
+func TestJanitor(t *testing.T) {
+	evicted := make(chan int, 2)
+	c := New[string](2, time.Millisecond, func(i int) { evicted <- i }, WithJanitor(time.Millisecond, 0))
+	defer c.Close()
+	c.Put("a", 1)
+	c.PutWithTTL("b", 2, time.Hour)
+	select {
+	case v := <-evicted:
+		if v != 1 {
+			t.Fatalf("janitor evicted %d, want 1", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("janitor did not remove expired 'a'")
+	}
+	if !c.Contains("b") {
+		t.Fatal("'b' should not have expired")
+	}
+}
+
+func TestJanitorLimit(t *testing.T) {
+	c := New[int](10, time.Millisecond, func(i int) {}, WithJanitor(time.Hour, 3))
+	defer c.Close()
+	for i := 0; i < 10; i++ {
+		c.Put(i, i)
+	}
+	time.Sleep(2 * time.Millisecond)
+	if n := c.removeExpired(3); n != 3 || c.Len() != 7 {
+		t.Fatalf("removed %d entries, %d left, want 3 and 7", n, c.Len())
+	}
+	if n := c.RemoveExpired(); n != 7 {
+		t.Fatalf("RemoveExpired removed %d entries, want the remaining 7", n)
+	}
+}
+
+func TestMaxExtensions(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {}, WithMaxExtensions(1))
+	c.Put("a", 1)
+	_, first, ok := c.GetWithExpiration("a")
+	if !ok {
+		t.Fatal("expected 'a'")
+	}
+	time.Sleep(time.Millisecond)
+	if _, exp, _ := c.GetWithExpiration("a"); !exp.Equal(first) {
+		t.Fatalf("expiration extended past the limit: %v, want %v", exp, first)
+	}
+	c.Put("a", 2)
+	time.Sleep(time.Millisecond)
+	if _, exp, _ := c.GetWithExpiration("a"); !exp.After(first) {
+		t.Fatal("Put should reset the extension count")
+	}
+}
+
+func TestMaxExtensionsTouchExtend(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {}, WithMaxExtensions(1))
+	c.Put("a", 1)
+	_, first, _ := c.GetWithExpiration("a")
+	time.Sleep(time.Millisecond)
+	if !c.Touch("a") {
+		t.Fatal("'a' should be live")
+	}
+	if c.Extend("a", time.Hour) {
+		t.Fatal("'a' should not be extended past the limit")
+	}
+	if _, exp, _ := c.GetWithExpiration("a"); !exp.Equal(first) {
+		t.Fatal("Touch and Extend should not extend 'a' past the limit")
+	}
+}
+
+func TestEvictionIgnoresTTL(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {})
+	c.Put("a", 1)
+	c.PutWithTTL("b", 2, time.Minute)
+	c.Put("c", 3)
+	if c.Contains("a") || !c.Contains("b") {
+		t.Fatalf("expected the least recently used 'a' to be evicted, have %v", c.Keys())
+	}
+}
+
+func TestEvictExpiredFirst(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {})
+	c.Put("live", 1)
+	c.PutWithTTL("short", 2, time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	c.Put("new", 3)
+	if keys := c.Keys(); fmt.Sprint(keys) != "[live new]" {
+		t.Fatalf("keys %v, want the expired 'short' evicted before the live 'live'", keys)
+	}
+}
+
+func TestEvictionCallback(t *testing.T) {
+	var got []string
+	c := New[string](1, time.Hour, func(i int) {}, WithEvictionCallback(func(k string, v int, reason EvictReason, _ any) {
+		got = append(got, fmt.Sprintf("%s=%d %v", k, v, reason))
+	}))
+	c.Put("a", 1)
+	c.Put("a", 2)
+	c.Put("b", 3)
+	c.ExpireMany([]string{"b"})
+	c.Put("c", 4)
+	c.Remove("c")
+	want := []string{"a=1 replaced", "a=2 capacity", "b=3 expired", "c=4 removed"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("callbacks %q, want %q", got, want)
+	}
+}
+
+func TestSampleKeys(t *testing.T) {
+	c := New[string](10, time.Hour, func(i int) {})
+	for i := 0; i < 10; i++ {
+		c.Put(strconv.Itoa(i), i)
+	}
+	for _, n := range []int{0, 3, 10, 20} {
+		keys := c.SampleKeys(n)
+		want := n
+		if want > 10 {
+			want = 10
+		}
+		seen := make(map[string]bool)
+		for _, k := range keys {
+			if seen[k] || !c.Contains(k) {
+				t.Fatalf("SampleKeys(%d) returned duplicate or unknown key %q", n, k)
+			}
+			seen[k] = true
+		}
+		if len(keys) != want {
+			t.Fatalf("SampleKeys(%d) returned %d keys, want %d", n, len(keys), want)
+		}
+	}
+}
+
+func TestAgeQuantiles(t *testing.T) {
+	c := New[string](3, time.Hour, func(i int) {})
+	if q := c.AgeQuantiles(0.5); q[0] != 0 {
+		t.Fatalf("empty cache age %v is not 0", q[0])
+	}
+	c.Put("a", 1)
+	time.Sleep(20 * time.Millisecond)
+	c.Put("b", 2)
+	c.Put("c", 3)
+	q := c.AgeQuantiles(0, 0.5, 1)
+	if q[1] >= 20*time.Millisecond || q[2] < 20*time.Millisecond || q[0] > q[1] {
+		t.Fatalf("unexpected age quantiles %v", q)
+	}
+}
+
+func TestMinResidency(t *testing.T) {
+	var evicted []int
+	c := New[string](2, time.Hour, func(i int) { evicted = append(evicted, i) }, WithMinResidency(20*time.Millisecond))
+	c.Put("a", 1)
+	time.Sleep(30 * time.Millisecond)
+	c.Put("b", 2)
+	c.Put("c", 3)
+	c.Put("d", 4)
+	if fmt.Sprint(evicted) != "[1 4]" {
+		t.Fatalf("evicted %v, want the old 'a' then the new 'd'", evicted)
+	}
+	if !c.Contains("b") || !c.Contains("c") {
+		t.Fatal("young entries should not be evicted")
+	}
+}
+
+func TestTryNew(t *testing.T) {
+	if _, err := TryNew[string](-1, time.Hour, func(i int) {}); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("negative size: got %v, want ErrInvalidConfig", err)
+	}
+	if _, err := TryNew[string](1, 0, func(i int) {}); !errors.Is(err, ErrInvalidTTL) {
+		t.Fatalf("zero ttl: got %v, want ErrInvalidTTL", err)
+	}
+	if _, err := TryNew[string](1, time.Hour, func(i int) {}, WithEvictionWatermarks(2, 1)); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("invalid watermarks: got %v, want ErrInvalidConfig", err)
+	}
+	if _, err := TryNew[string](1, time.Hour, func(i int) {}, WithValidator(func(k int, v int) bool { return true })); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("mismatched validator: got %v, want ErrInvalidConfig", err)
+	}
+	for _, opt := range []Option{WithRecentlyEvicted(-1), WithMaxExtensions(-1), WithJanitor(-time.Second, 0), WithJanitor(time.Second, -1),
+		WithRandomEviction(-1), WithWarmup(-1), WithMaxLifetime(-time.Second), WithWarmupHitRatio(0, 1),
+		WithWarmupHitRatio(0.5, -1)} {
+		if _, err := TryNew[string](1, time.Hour, func(i int) {}, opt); !errors.Is(err, ErrInvalidConfig) {
+			t.Fatalf("negative option argument: got %v, want ErrInvalidConfig", err)
+		}
+	}
+	c, err := TryNew[string](1, time.Hour, func(i int) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Put("a", 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNilOnEvicted(t *testing.T) {
+	c := New[string, int](1, time.Hour, nil)
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Remove("b")
+	c.Put("c", 3)
+	c.Purge()
+}
+
+func TestReplaceAll(t *testing.T) {
+	var got []string
+	c := New[string](3, time.Hour, func(i int) {}, WithEvictionCallback(func(k string, v int, reason EvictReason, _ any) {
+		got = append(got, fmt.Sprintf("%s=%d %v", k, v, reason))
+	}))
+	c.Put("a", 1)
+	c.Put("b", 2)
+	err := c.ReplaceAll([]Entry[string, int]{{Key: "b", Value: 3}, {Key: "c", Value: 4, TTL: time.Minute}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Contains("a") || c.Len() != 2 {
+		t.Fatalf("keys %v, want [b c]", c.Keys())
+	}
+	if v, _ := c.Get("b"); v != 3 {
+		t.Fatalf("'b' value %d is not 3", v)
+	}
+	if d, _ := c.TTL("c"); d > time.Minute {
+		t.Fatalf("'c' ttl %v is longer than its own", d)
+	}
+	want := []string{"a=1 removed", "b=2 replaced"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("callbacks %q, want %q", got, want)
+	}
+	if err := c.ReplaceAll([]Entry[string, int]{{Key: "d", TTL: -1}}); !errors.Is(err, ErrInvalidTTL) || c.Len() != 2 {
+		t.Fatalf("negative ttl: got %v with %d entries, want ErrInvalidTTL and no change", err, c.Len())
+	}
+}
+
+func TestReplaceAllReplacedNotRecorded(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {}, WithRecentlyEvicted(2))
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.ReplaceAll([]Entry[string, int]{{Key: "a", Value: 3}})
+	if got := c.RecentlyEvicted(); len(got) != 1 || got[0].Key != "b" {
+		t.Fatalf("recently evicted %v, want only the removed 'b'", got)
+	}
+	if s := c.Stats(); s.Replacements != 1 || s.Removals != 1 {
+		t.Fatalf("stats %+v, want 1 replacement and 1 removal", s)
+	}
+}
+
+func TestReplaceAllOverflow(t *testing.T) {
+	var evicted []int
+	c := New[string](2, time.Hour, func(i int) { evicted = append(evicted, i) }, WithRestoreWindow(time.Hour),
+		WithTransform(func(i int) (int, error) { return i * 10, nil }))
+	c.Put("old", 1)
+	c.RemoveSoft("old")
+	entries := []Entry[string, int]{{Key: "a", Value: 0}, {Key: "b", Value: 1}, {Key: "c", Value: 2}, {Key: "d", Value: 3}}
+	if err := c.ReplaceAll(entries); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(evicted) != "[10]" {
+		t.Fatalf("evicted %v, want only the soft removed 10", evicted)
+	}
+	if c.Restore("old") {
+		t.Fatal("soft removed entries should not survive ReplaceAll")
+	}
+	if keys := c.Keys(); fmt.Sprint(keys) != "[c d]" {
+		t.Fatalf("keys %v, want [c d]", keys)
+	}
+	if v, _ := c.Get("d"); v != 30 {
+		t.Fatalf("'d' value %d is not the transformed 30", v)
+	}
+}
+
+func TestKeysPageNoLimit(t *testing.T) {
+	c := New[string](3, time.Hour, func(i int) {})
+	for i := 0; i < 3; i++ {
+		c.Put(strconv.Itoa(i), i)
+	}
+	for _, limit := range []int{0, -1} {
+		if keys, next := c.KeysPage(1, limit); len(keys) != 2 || next != 0 {
+			t.Fatalf("limit %d: got %v, %d, want the 2 remaining keys and 0", limit, keys, next)
+		}
+	}
+}
+
+func TestUpdateReadOnly(t *testing.T) {
+	c := New[string](1, time.Hour, func(i int) {})
+	c.PutReadOnly("a", 1)
+	if c.Update("a", func(v *int) { *v = 2 }) {
+		t.Fatal("Update should not modify a read-only entry")
+	}
+	if v, _ := c.Get("a"); v != 1 {
+		t.Fatalf("'a' value %d is not 1", v)
+	}
+}
+
+func TestFIFOTouch(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {}, WithFIFO())
+	c.Put("a", 1)
+	c.Put("b", 2)
+	_, exp, _ := c.GetWithExpiration("a")
+	time.Sleep(time.Millisecond)
+	c.Touch("a")
+	if _, again, _ := c.GetWithExpiration("a"); !again.Equal(exp) {
+		t.Fatal("Touch should not move the expiration of a FIFO entry")
+	}
+	c.PutWithTTL("a", 3, time.Minute)
+	c.Put("c", 4)
+	if c.Contains("a") || !c.Contains("b") {
+		t.Fatalf("keys %v, want 'a' evicted first", c.Keys())
+	}
+}
+
+func TestEvictionCallbackHandOver(t *testing.T) {
+	var got []string
+	c := New[string](2, time.Hour, func(i int) { t.Errorf("onEvicted called for handed over %d", i) },
+		WithEvictionCallback(func(k string, v int, reason EvictReason, _ any) {
+			got = append(got, fmt.Sprintf("%s=%d %v", k, v, reason))
+		}))
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.RemoveAndGet("a")
+	c.RemoveOldest()
+	if want := "[a=1 removed b=2 removed]"; fmt.Sprint(got) != want {
+		t.Fatalf("callbacks %v, want %s", got, want)
+	}
+}
+
+func TestEvictionCallbackTag(t *testing.T) {
+	var tags []any
+	c := New[string](1, time.Hour, func(i int) {}, WithEvictionCallback(func(k string, v int, reason EvictReason, tag any) {
+		tags = append(tags, tag)
+	}))
+	c.PutWithTag("a", 1, "x")
+	c.Put("b", 2)
+	if len(tags) != 1 || tags[0] != "x" {
+		t.Fatalf("callback got tags %v, want [x]", tags)
+	}
+}
+
+func TestMoveExpired(t *testing.T) {
+	src := New[string](1, time.Hour, func(i int) {})
+	dst := New[string](1, time.Hour, func(i int) {})
+	src.Put("a", 1)
+	src.Expire("a")
+	if src.Move(dst, "a") || dst.Contains("a") {
+		t.Fatal("expired entries should not be moved")
+	}
+}
+
+func TestRecentlyEvicted(t *testing.T) {
+	c := New[string](1, time.Hour, func(i int) {}, WithRecentlyEvicted(2))
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+	c.Remove("c")
+	got := c.RecentlyEvicted()
+	if len(got) != 2 {
+		t.Fatalf("got %d evictions, want 2", len(got))
+	}
+	if got[0].Key != "b" || got[0].Reason != Capacity {
+		t.Fatalf("got %v, want b evicted for capacity", got[0])
+	}
+	if got[1].Key != "c" || got[1].Reason != Removed {
+		t.Fatalf("got %v, want c removed", got[1])
+	}
+}
+
+func TestMaxLifetime(t *testing.T) {
+	evicted := 0
+	c := New[string](2, time.Hour, func(i int) { evicted++ }, WithMaxLifetime(10*time.Millisecond))
+	c.Put("a", 1)
+	for i := 0; i < 3; i++ {
+		time.Sleep(5 * time.Millisecond)
+		c.Get("a")
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("'a' should have outlived its max lifetime")
+	}
+	if evicted != 1 {
+		t.Fatalf("onEvicted called %d times, want 1", evicted)
+	}
+}
+
+func TestWriteCoalescing(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {}, WithWriteCoalescing(time.Hour))
+	c.Put("a", 1)
+	item, _ := c.items.Get("a")
+	expire := item.expire
+	c.Put("a", 2)
+	if !item.expire.Equal(expire) {
+		t.Fatal("coalesced Put should not refresh the expiration")
+	}
+	if v, _ := c.Get("a"); v != 2 {
+		t.Fatalf("'a' value %d is not 2", v)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	c := New[string](1, time.Hour, func(i int) {})
+	r := c.AsReadOnly()
+	c.Put("a", 1)
+	if v, ok := r.Get("a"); !ok || v != 1 {
+		t.Fatalf("read-only Get returned %d, %v", v, ok)
+	}
+}
+
+func TestTag(t *testing.T) {
+	c := New[string](1, time.Hour, func(i int) {}, WithRecentlyEvicted(1))
+	c.PutWithTag("a", 1, "origin")
+	c.Put("a", 2)
+	if tag, _ := c.Tag("a"); tag != "origin" {
+		t.Fatalf("tag %v is not 'origin'", tag)
+	}
+	c.Remove("a")
+	if got := c.RecentlyEvicted(); got[0].Tag != "origin" {
+		t.Fatalf("eviction tag %v is not 'origin'", got[0].Tag)
+	}
+}
+
+func TestShrinkToFit(t *testing.T) {
+	c := New[string](10, time.Hour, func(i int) {})
+	for i := 0; i < 10; i++ {
+		c.Put(strconv.Itoa(i), i)
+	}
+	for i := 0; i < 8; i++ {
+		c.Remove(strconv.Itoa(i))
+	}
+	c.ShrinkToFit()
+	if v, ok := c.Get("9"); !ok || v != 9 {
+		t.Fatalf("'9' value %d is not 9", v)
+	}
+	c.Put("a", 1)
+	c.Put("b", 2)
+	if l := c.items.Len(); l != 4 {
+		t.Fatalf("items size %d is not 4", l)
+	}
+}
+
+func TestAutoShrink(t *testing.T) {
+	c := New[int](1000, time.Hour, func(i int) {})
+	for i := 0; i < 1000; i++ {
+		c.Put(i, i)
+	}
+	if n := c.ExpireWhere(func(k, v int) bool { return k < 500 }); n != 500 || c.items.Cap() != 1000 {
+		t.Fatalf("removed %d, capacity %d, want 500 and 1000", n, c.items.Cap())
+	}
+	c.ExpireWhere(func(k, v int) bool { return k < 900 })
+	if n := c.items.Cap(); n != 100 {
+		t.Fatalf("capacity %d after removing most entries is not 100", n)
+	}
+	if v, ok := c.Get(950); !ok || v != 950 {
+		t.Fatalf("950 value %d is not 950", v)
+	}
+	c.Purge()
+	if n := c.items.Cap(); n != 0 {
+		t.Fatalf("capacity %d after Purge is not 0", n)
+	}
+}
+
+func TestExpirationHistogram(t *testing.T) {
+	c := New[string](3, time.Minute, func(i int) {})
+	c.Put("a", 1)
+	c.Put("b", 2)
+	got := c.ExpirationHistogram(time.Second, 2*time.Minute)
+	if got[0] != 0 || got[1] != 2 {
+		t.Fatalf("histogram %v is not [0 2]", got)
+	}
+}
+
+func TestKeysPage(t *testing.T) {
+	c := New[string](5, time.Hour, func(i int) {})
+	for i := 0; i < 5; i++ {
+		c.Put(strconv.Itoa(i), i)
+	}
+	seen := make(map[string]bool)
+	pages := 0
+	for cursor := 0; ; {
+		var keys []string
+		keys, cursor = c.KeysPage(cursor, 2)
+		pages++
+		for _, k := range keys {
+			seen[k] = true
+		}
+		if cursor == 0 {
+			break
+		}
+	}
+	if len(seen) != 5 || pages != 3 {
+		t.Fatalf("saw %d keys in %d pages, want 5 in 3", len(seen), pages)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	evicted := 0
+	c := New[string](0, time.Hour, func(i int) { evicted++ })
+	c.Put("a", 1)
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("disabled cache should always miss")
+	}
+	if evicted != 1 {
+		t.Fatalf("onEvicted called %d times, want 1", evicted)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	c := New[string](1, time.Hour, func(i int) {})
+	c.Put("a", 1)
+	if !c.Update("a", func(v *int) { *v++ }) {
+		t.Fatal("'a' should be in the cache")
+	}
+	if v, _ := c.Get("a"); v != 2 {
+		t.Fatalf("'a' value %d is not 2", v)
+	}
+	if c.Update("b", func(v *int) { t.Fatal("fn called for a missing key") }) {
+		t.Fatal("'b' should not be in the cache")
+	}
+}
+
+func TestEvictionWatermarks(t *testing.T) {
+	evicted := 0
+	c := New[string](4, time.Hour, func(i int) { evicted++ }, WithEvictionWatermarks(1, 3))
+	for i := 0; i < 3; i++ {
+		c.Put(strconv.Itoa(i), i)
+	}
+	if evicted != 0 {
+		t.Fatalf("evicted %d before reaching the high watermark", evicted)
+	}
+	c.Put("3", 3)
+	if evicted != 2 {
+		t.Fatalf("evicted %d, want 2 to reach the low watermark", evicted)
+	}
+	if l := c.items.Len(); l != 2 {
+		t.Fatalf("items size %d is not 2", l)
+	}
+}
+
+func TestEvictionWatermarksLowZero(t *testing.T) {
+	c := New[int](10, time.Hour, func(i int) {}, WithEvictionWatermarks(0, 10))
+	for i := 0; i < 11; i++ {
+		c.Put(i, i)
+	}
+	if l := c.items.Len(); l != 1 {
+		t.Fatalf("items size %d, want 1 after evicting down to the low watermark of 0", l)
+	}
+}
+
+func TestBypass(t *testing.T) {
+	c := New[string](1, time.Hour, func(i int) {})
+	c.Put("a", 1)
+	c.SetBypassProbability(1)
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("Get should be bypassed")
+	}
+	c.SetBypassProbability(0)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("'a' should still be in the cache")
+	}
+}
+
+func TestValidator(t *testing.T) {
+	version := 1
+	c := New[string](1, time.Hour, func(i int) {}, WithValidator(func(k string, v int) bool {
+		return v == version
+	}))
+	c.Put("a", 1)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("'a' should be valid")
+	}
+	version = 2
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("'a' should be stale")
+	}
+	if l := c.items.Len(); l != 0 {
+		t.Fatalf("items size %d is not 0", l)
+	}
+}
+
+func TestExpiryAlignment(t *testing.T) {
+	c := New[string](1, time.Second, func(i int) {}, WithExpiryAlignment(time.Minute))
+	c.Put("a", 1)
+	item, _ := c.items.Get("a")
+	if !item.expire.Equal(item.expire.Truncate(time.Minute)) {
+		t.Fatalf("expiration %v is not aligned to the minute", item.expire)
+	}
+	if item.expire.Before(item.created.Add(time.Second)) {
+		t.Fatalf("expiration %v is before the ttl elapses", item.expire)
+	}
+}
+
+func TestFIFO(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {}, WithFIFO())
+	c.Put("a", 1)
+	time.Sleep(time.Millisecond)
+	c.Put("b", 2)
+	c.Get("a")
+	c.Put("a", 3)
+	c.Put("c", 4)
+	if _, e := c.Get("a"); e {
+		t.Fatal("'a' should have been evicted first")
+	}
+}
+
+func TestWarmup(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {}, WithWarmup(2))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := c.WaitWarm(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("WaitWarm returned %v on a cold cache", err)
+	}
+	c.Put("a", 1)
+	c.Put("a", 2)
+	if c.WarmedUp() {
+		t.Fatal("cache with 1 entry should not be warm")
+	}
+	c.Put("b", 3)
+	if err := c.WaitWarm(context.Background()); err != nil || !c.WarmedUp() {
+		t.Fatal("cache with 2 entries should be warm")
+	}
+}
+
+func TestWarmupHitRatio(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {}, WithWarmupHitRatio(0.5, 4))
+	c.Put("a", 1)
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+	if c.WarmedUp() {
+		t.Fatal("cache should not be warm before 4 lookups")
+	}
+	c.Get("b")
+	if !c.WarmedUp() {
+		t.Fatal("cache with a hit ratio of 0.5 should be warm")
+	}
+	c.Get("b")
+	if !c.WarmedUp() {
+		t.Fatal("cache should stay warm when its hit ratio drops")
+	}
+	c = New[string](2, time.Hour, func(i int) {}, WithWarmup(1), WithWarmupHitRatio(1, 0))
+	c.Get("a")
+	c.Put("a", 1)
+	if !c.WarmedUp() {
+		t.Fatal("cache should be warm once it holds 1 entry")
+	}
+}
+
+func TestPin(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {})
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Pin("a")
+	c.Put("c", 3)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("pinned 'a' should not have been evicted")
+	}
+	c.Pin("c")
+	c.Put("d", 4)
+	if _, ok := c.Get("d"); ok {
+		t.Fatal("'d' should not fit in a fully pinned cache")
+	}
+	c.Unpin("a")
+	c.Put("d", 4)
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("'a' should have been evicted after Unpin")
+	}
+	t.Run("PinFor", func(t *testing.T) {
+		c := New[string](1, time.Hour, func(i int) {})
+		c.Put("a", 1)
+		c.PinFor("a", time.Millisecond)
+		time.Sleep(2 * time.Millisecond)
+		c.Put("b", 2)
+		if _, ok := c.Get("a"); ok {
+			t.Fatal("'a' should have been evicted after its lease expired")
+		}
+	})
+}
+
 func BenchmarkPutRemove(b *testing.B) {
 	b.Run("SmallCacheSmallItem", func(b *testing.B) {
 		c := New[string](1, time.Hour, func(i int) {})
@@ -116,6 +1187,107 @@ func BenchmarkEviction(b *testing.B) {
 	}
 }
 
+// BenchmarkMemoryOverhead compares configurations of a cache of 10,000 entries. For each, it
+// reports the time and bytes allocated by a Put evicting another entry (ns/op, and B/op with
+// -benchmem), the bytes allocated per entry when filling the cache (B/entry), and the bytes each
+// entry takes in the cache's structures as read from their types (struct-B/entry). With -v, it
+// also logs the results as a table:
+//
+//	go test -run - -bench MemoryOverhead -benchmem -v
+func BenchmarkMemoryOverhead(b *testing.B) {
+	const size = 10_000
+	// twice as many keys as the cache holds, so that every Put evicts.
+	keys := make([]string, 2*size)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	type result struct {
+		name                      string
+		nsPerOp, bytesPerOp       float64
+		bytesPerEntry, structSize float64
+	}
+	var results []result
+	for _, cfg := range []struct {
+		name string
+		opts []Option
+	}{
+		{"Default", nil},
+		{"FIFO", []Option{WithFIFO()}},
+		{"RandomEviction", []Option{WithRandomEviction(5)}},
+		{"Watermarks", []Option{WithEvictionWatermarks(size*9/10, size)}},
+		{"MinResidency", []Option{WithMinResidency(time.Nanosecond)}},
+		{"RecentlyEvicted", []Option{WithRecentlyEvicted(size)}},
+		{"OrderedIndex", []Option{WithOrderedIndex[string]()}},
+		{"Shadow", []Option{WithShadow(2 * size)}},
+		{"DebugStats", []Option{WithDebugStats()}},
+	} {
+		var r result
+		b.Run(cfg.name, func(b *testing.B) {
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+			c := New[string](size, time.Hour, func(i int) {}, cfg.opts...)
+			for i, k := range keys[:size] {
+				c.Put(k, i)
+			}
+			runtime.ReadMemStats(&after)
+			r = result{
+				name:          cfg.name,
+				bytesPerEntry: float64(after.TotalAlloc-before.TotalAlloc) / size,
+				structSize:    float64(structBytes(c)),
+			}
+			runtime.ReadMemStats(&before)
+			b.ResetTimer()
+			start := time.Now()
+			for n := 0; n < b.N; n++ {
+				c.Put(keys[(size+n)%len(keys)], n)
+			}
+			r.nsPerOp = float64(time.Since(start).Nanoseconds()) / float64(b.N)
+			b.StopTimer()
+			runtime.ReadMemStats(&after)
+			r.bytesPerOp = float64(after.TotalAlloc-before.TotalAlloc) / float64(b.N)
+			b.ReportMetric(r.bytesPerEntry, "B/entry")
+			b.ReportMetric(r.structSize, "struct-B/entry")
+		})
+		if r.name != "" { // not skipped by -bench
+			results = append(results, r)
+		}
+	}
+	var table strings.Builder
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "configuration\tns/op\tB/op\tB/entry\tstruct-B/entry\t")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%.0f\t%.0f\t%.0f\t%.0f\t\n", r.name, r.nsPerOp, r.bytesPerOp, r.bytesPerEntry, r.structSize)
+	}
+	w.Flush()
+	b.Log("\n" + table.String())
+}
+
+// structBytes returns the bytes each of c's entries takes in the cache's structures, read from
+// their types: its item, heap node, heap slot and map entry, plus its share of the ordered index,
+// shadow and eviction log of caches that have them. Overheads of the map and skip list beyond
+// their entries are not counted.
+func structBytes(c *Cache[string, int]) uintptr {
+	ptr := reflect.TypeOf(uintptr(0)).Size()
+	heap := reflect.TypeOf(c.items).Elem()
+	nodes, _ := heap.FieldByName("nodes")
+	slots, _ := nodes.Type.FieldByName("s")
+	keys, _ := heap.FieldByName("keys")
+	n := reflect.TypeOf(item[string, int]{}).Size() +
+		slots.Type.Elem().Elem().Size() + slots.Type.Elem().Size() +
+		keys.Type.Key().Size() + keys.Type.Elem().Size()
+	if c.index != nil {
+		// a node is on 4/3 levels on average, see skipList.insert.
+		n += reflect.TypeOf(skipNode[string]{}).Size() + ptr*4/3
+	}
+	if c.shadow != nil {
+		key := reflect.TypeOf(item[string, struct{}]{}).Size() + reflect.TypeOf("").Size() + ptr
+		n += key * uintptr(c.shadow.size) / uintptr(c.size)
+	}
+	n += reflect.TypeOf(Eviction[string]{}).Size() * uintptr(len(c.evictions.buf)) / uintptr(c.size)
+	return n
+}
+
 func BenchmarkAccess(b *testing.B) {
 	type S struct{ i int }
 	b.Run("MapToPtr", func(b *testing.B) {
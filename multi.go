@@ -0,0 +1,35 @@
+package lru
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidLimit is returned by AppendTo for a limit below 1.
+var ErrInvalidLimit = errors.New("lru: limit must be positive")
+
+// AppendTo appends v to the values stored for k, dropping the oldest values so that at most limit
+// remain. Values of an expired entry are dropped rather than appended to. The stored slice is never
+// modified in place, slices returned by earlier Gets are safe to keep using. It returns ErrReadOnly
+// if k was put with PutReadOnly, and ErrInvalidLimit if limit is below 1.
+func AppendTo[K comparable, E any](c *Cache[K, []E], k K, v E, limit int) error {
+	if limit < 1 {
+		return ErrInvalidLimit
+	}
+	c.lock()
+	defer c.mu.Unlock()
+	var values []E
+	var ttl time.Duration
+	if item, exists := c.items.Get(k); exists {
+		ttl = item.ttl
+		if c.live(item, time.Now()) {
+			values = item.v
+		}
+	}
+	values = append(values[:len(values):len(values)], v)
+	if len(values) > limit {
+		values = values[len(values)-limit:]
+	}
+	_, err := c.put(k, values, ttl)
+	return err
+}
@@ -0,0 +1,40 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAppendTo(t *testing.T) {
+	c := New[string](1, time.Hour, func(v []int) {})
+	AppendTo(c, "a", 1, 2)
+	before, _ := c.Get("a")
+	AppendTo(c, "a", 2, 2)
+	AppendTo(c, "a", 3, 2)
+	got, _ := c.Get("a")
+	if len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Fatalf("values %v are not [2 3]", got)
+	}
+	if len(before) != 1 || before[0] != 1 {
+		t.Fatalf("earlier values %v were modified", before)
+	}
+}
+
+func TestAppendToExpired(t *testing.T) {
+	c := New[string](1, 10*time.Millisecond, func(v []int) {})
+	AppendTo(c, "a", 1, 2)
+	time.Sleep(20 * time.Millisecond)
+	AppendTo(c, "a", 2, 2)
+	if got, _ := c.Get("a"); len(got) != 1 || got[0] != 2 {
+		t.Fatalf("values %v are not [2]", got)
+	}
+}
+
+func TestAppendToInvalidLimit(t *testing.T) {
+	c := New[string](1, time.Hour, func(v []int) {})
+	for _, limit := range []int{0, -1} {
+		if err := AppendTo(c, "a", 1, limit); err != ErrInvalidLimit {
+			t.Fatalf("limit %d: got %v, want ErrInvalidLimit", limit, err)
+		}
+	}
+}
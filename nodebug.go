@@ -0,0 +1,5 @@
+//go:build !lrudebug
+
+package lru
+
+func assert(cond bool, msg string) {}
@@ -0,0 +1,315 @@
+package lru
+
+import "time"
+
+// Option configures optional behavior of a Cache.
+type Option func(*options)
+
+type options struct {
+	recentlyEvicted int
+	maxLifetime     time.Duration
+	coalesce        time.Duration
+	low, high       int
+	validator       any // func(K, V) bool
+	align           time.Duration
+	fifo            bool
+	warmAt          int
+	warmRatio       float64
+	warmLookups     int
+	overflow        any // func(K, V) bool
+	canonical       any // func(K) K
+	index           any // func() keyIndex[K]
+	onEvict         any // func(K, V, EvictReason, any)
+	debugStats      bool
+	transforms      []any // func(V) (V, error)
+	samples         int
+	janitor         time.Duration
+	sweepLimit      int
+	extensions      int
+	minResidency    time.Duration
+	inst            Instrumentation
+	debugLog        func(msg string, args ...any)
+	restoreWindow   time.Duration
+	loadLimit       int
+	errorTTL        time.Duration
+	detectInterval  time.Duration
+	anomalyFactor   float64
+	anomalies       func(Anomaly)
+	shadow          int
+
+	invalid string // describes an invalid option argument
+}
+
+// WithRecentlyEvicted keeps the keys of the last n evicted entries, see Cache.RecentlyEvicted.
+func WithRecentlyEvicted(n int) Option {
+	return func(o *options) {
+		if n < 0 {
+			o.invalid = "recently evicted count must not be negative"
+			return
+		}
+		o.recentlyEvicted = n
+	}
+}
+
+// WithMaxLifetime bounds how long a value may be served after it was Put, no matter how often
+// it is accessed. Get treats older entries as missing and removes them.
+func WithMaxLifetime(d time.Duration) Option {
+	return func(o *options) {
+		if d < 0 {
+			o.invalid = "max lifetime must not be negative"
+			return
+		}
+		o.maxLifetime = d
+	}
+}
+
+// WithMaxExtensions limits how many times Get, Touch and Extend may extend an entry's expiration
+// before the entry has to be Put again. Once the limit is reached, the entry expires at its current expiration no
+// matter how often it is read. See WithMaxLifetime to bound the total time instead.
+func WithMaxExtensions(n int) Option {
+	return func(o *options) {
+		if n < 0 {
+			o.invalid = "max extensions must not be negative"
+			return
+		}
+		o.extensions = n
+	}
+}
+
+// WithWriteCoalescing makes a Put to a key that was refreshed less than window ago only replace
+// the value, without refreshing its expiration. Rapid successive writes to a hot key then cost a
+// single heap update per window.
+func WithWriteCoalescing(window time.Duration) Option {
+	return func(o *options) {
+		if window < 0 {
+			o.invalid = "write coalescing window must not be negative"
+			return
+		}
+		o.coalesce = window
+	}
+}
+
+// WithEvictionWatermarks makes Put evict in batches: once the cache holds high entries, adding
+// another evicts the oldest entries until only low remain. high is capped at the cache size.
+func WithEvictionWatermarks(low, high int) Option {
+	return func(o *options) {
+		if low < 0 || low >= high {
+			o.invalid = "low watermark must be non-negative and below the high watermark"
+			return
+		}
+		o.low, o.high = low, high
+	}
+}
+
+// WithValidator makes Get check every hit with valid; entries it rejects are treated as stale,
+// removed and reported as misses. valid's types must match the cache's.
+func WithValidator[K comparable, V any](valid func(K, V) bool) Option {
+	return func(o *options) {
+		o.validator = valid
+	}
+}
+
+// WithExpiryAlignment rounds every expiration up to the next multiple of d since the zero time,
+// e.g. to the top of the minute for time.Minute, so that caches on different machines expire a
+// given entry at the same moment.
+func WithExpiryAlignment(d time.Duration) Option {
+	return func(o *options) {
+		if d < 0 {
+			o.invalid = "expiry alignment must not be negative"
+			return
+		}
+		o.align = d
+	}
+}
+
+// WithFIFO disables recency tracking: entries expire ttl after they were first put and are
+// evicted in insertion order, no matter how often they are accessed or replaced. Accesses then
+// never reorder the cache's structures. Entries take as much memory as without it.
+func WithFIFO() Option {
+	return func(o *options) {
+		o.fifo = true
+	}
+}
+
+// WithWarmup makes the cache report being warmed up only once it has held minEntries entries,
+// see Cache.WarmedUp and Cache.WaitWarm. Without it or WithWarmupHitRatio a cache is warm from the
+// start. With both, the cache is warm once either condition is met.
+func WithWarmup(minEntries int) Option {
+	return func(o *options) {
+		if minEntries < 0 {
+			o.invalid = "warmup entries must not be negative"
+			return
+		}
+		o.warmAt = minEntries
+	}
+}
+
+// WithWarmupHitRatio makes the cache report being warmed up only once at least minRatio of its
+// lookups were hits, counted after at least minLookups lookups so that the first few don't decide
+// alone. See WithWarmup to wait for a number of entries instead.
+func WithWarmupHitRatio(minRatio float64, minLookups int) Option {
+	return func(o *options) {
+		if !(minRatio > 0 && minRatio <= 1) || minLookups < 0 {
+			o.invalid = "warmup hit ratio must be in (0, 1] and lookups must not be negative"
+			return
+		}
+		o.warmRatio = minRatio
+		o.warmLookups = minLookups
+	}
+}
+
+// WithOverflow makes the cache offer every entry evicted to make room to handle first, e.g. to
+// spill it to a second tier. If handle returns true it took the entry over and onEvicted is not
+// called; otherwise the entry is evicted as usual. handle's types must match the cache's.
+func WithOverflow[K comparable, V any](handle func(K, V) bool) Option {
+	return func(o *options) {
+		o.overflow = handle
+	}
+}
+
+// WithEvictionCallback sets a callback called with the key, value, reason and tag (see
+// PutWithTag) of every entry that leaves the cache, alongside New's onEvicted, and with the old
+// value of every entry that is replaced. Unlike onEvicted, it is also called for values handed
+// over by RemoveAndGet and RemoveOldest. It is not called for entries dropped by Clear or moved
+// to another cache by Move. fn's types must match the cache's.
+func WithEvictionCallback[K comparable, V any](fn func(k K, v V, reason EvictReason, tag any)) Option {
+	return func(o *options) {
+		o.onEvict = fn
+	}
+}
+
+// WithDebugStats makes the cache count operations on its internal structures, see Cache.DebugStats.
+func WithDebugStats() Option {
+	return func(o *options) {
+		o.debugStats = true
+	}
+}
+
+// WithTransform makes the cache store transform(v) whenever v is put, so that expensive
+// post-processing such as parsing happens once per insert rather than after every Get. Several
+// transforms are applied in the order given. If one fails, the Put returns its error and stores
+// nothing. transform's types must match the cache's.
+func WithTransform[V any](transform func(V) (V, error)) Option {
+	return func(o *options) {
+		o.transforms = append(o.transforms, transform)
+	}
+}
+
+// WithRandomEviction makes capacity eviction pick the first entry to expire among samples randomly
+// chosen entries, rather than the least recently used. Eviction still favors old entries, but
+// clients can't predict which entry their inserts will flush out of the cache.
+func WithRandomEviction(samples int) Option {
+	return func(o *options) {
+		if samples < 0 {
+			o.invalid = "random eviction samples must not be negative"
+			return
+		}
+		o.samples = samples
+	}
+}
+
+// WithJanitor starts a goroutine removing expired entries every interval, calling onEvicted for
+// each, instead of leaving them in the cache until they are looked up or evicted. Each run removes
+// at most maxPerSweep entries, or all expired entries if it is 0, so that a mass expiry is spread
+// over several runs instead of holding the cache's lock for long. The goroutine keeps the cache
+// alive until Cache.Close is called.
+func WithJanitor(interval time.Duration, maxPerSweep int) Option {
+	return func(o *options) {
+		if interval < 0 || maxPerSweep < 0 {
+			o.invalid = "janitor interval and max per sweep must not be negative"
+			return
+		}
+		o.janitor = interval
+		o.sweepLimit = maxPerSweep
+	}
+}
+
+// WithMinResidency protects entries from capacity eviction for d after they were Put, so that a
+// burst of inserts can't flush out entries that were just loaded. Like pinned entries, protected
+// entries still expire. If every entry is protected, a new entry is evicted right away instead.
+func WithMinResidency(d time.Duration) Option {
+	return func(o *options) {
+		if d < 0 {
+			o.invalid = "min residency must not be negative"
+			return
+		}
+		o.minResidency = d
+	}
+}
+
+// WithInstrumentation reports every hit, miss, put and entry leaving the cache to inst as it
+// happens, in addition to the counters returned by Stats.
+func WithInstrumentation(inst Instrumentation) Option {
+	return func(o *options) {
+		o.inst = inst
+	}
+}
+
+// WithRestoreWindow makes RemoveSoft keep removed entries for d, so that Restore can undo the
+// removal.
+func WithRestoreWindow(d time.Duration) Option {
+	return func(o *options) {
+		if d < 0 {
+			o.invalid = "restore window must not be negative"
+			return
+		}
+		o.restoreWindow = d
+	}
+}
+
+// WithLoadLimit makes GetOrLoad run at most n loads at once, for all keys together; further loads
+// queue until one finishes. Loads of the same key are always shared. Use GetOrLoadContext to bound
+// the wait.
+func WithLoadLimit(n int) Option {
+	return func(o *options) {
+		if n < 0 {
+			o.invalid = "load limit must not be negative"
+			return
+		}
+		o.loadLimit = n
+	}
+}
+
+// WithErrorTTL makes GetOrLoad cache the errors returned by its loads for ttl, returning them
+// without loading again until they expire or the key is put, see Cache.GetResult. Values keep
+// the cache's ttl. Errors from loads whose context was done, and panics, are not cached. At most as
+// many errors are kept as the cache holds entries.
+func WithErrorTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		if ttl < 0 {
+			o.invalid = "error ttl must not be negative"
+			return
+		}
+		o.errorTTL = ttl
+	}
+}
+
+// WithAnomalyDetector starts a goroutine comparing the cache's Stats every interval with those of
+// the interval before, and calling fn when the miss ratio or the number of capacity evictions is
+// factor times what it was or more, see Anomaly. A metric growing from 0 is always reported. fn is
+// called from the goroutine, which keeps the cache alive until Cache.Close is called.
+func WithAnomalyDetector(interval time.Duration, factor float64, fn func(Anomaly)) Option {
+	return func(o *options) {
+		if interval <= 0 || !(factor > 1) || fn == nil {
+			o.invalid = "anomaly detector needs a positive interval, a factor above 1 and a callback"
+			return
+		}
+		o.detectInterval = interval
+		o.anomalyFactor = factor
+		o.anomalies = fn
+	}
+}
+
+// WithShadow runs a keys-only LRU cache holding size keys alongside the cache, fed the same
+// lookups, puts and removals, and counts its hits and misses in Stats.ShadowHits and
+// Stats.ShadowMisses. Comparing Stats.ShadowHitRatio with Stats.HitRatio shows whether resizing the
+// cache to size would pay off, without storing any values. The shadow ignores expiration.
+func WithShadow(size int) Option {
+	return func(o *options) {
+		if size < 0 {
+			o.invalid = "shadow size must not be negative"
+			return
+		}
+		o.shadow = size
+	}
+}
@@ -0,0 +1,169 @@
+package lru
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Ordered is a constraint for key types that support the < operator.
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 |
+		~string
+}
+
+// WithOrderedIndex keeps the cache's keys sorted, so that RangeBetween and RemoveRange only visit
+// the entries in their range instead of scanning every entry. It costs O(log n) more work and a
+// few pointers of memory per entry when adding and removing entries. K must match the cache's key
+// type.
+func WithOrderedIndex[K Ordered]() Option {
+	return func(o *options) {
+		o.index = func() keyIndex[K] { return newSkipList[K]() }
+	}
+}
+
+// keyIndex is an ordered index of a cache's keys, see WithOrderedIndex.
+type keyIndex[K comparable] interface {
+	insert(k K)
+	remove(k K)
+	reset()
+}
+
+// maxLevel bounds the height of a skipList, enough for 4^maxLevel keys.
+const maxLevel = 16
+
+type skipNode[K Ordered] struct {
+	k    K
+	next []*skipNode[K] // next node on each level the node is on
+}
+
+// skipList is a keyIndex keeping keys in a skip list, ascending.
+type skipList[K Ordered] struct {
+	head   skipNode[K] // sentinel, on every level
+	levels int         // levels in use
+}
+
+func newSkipList[K Ordered]() *skipList[K] {
+	l := new(skipList[K])
+	l.head.next = make([]*skipNode[K], maxLevel)
+	l.levels = 1
+	return l
+}
+
+// before fills path with the last node before k on each level in use.
+func (l *skipList[K]) before(k K, path *[maxLevel]*skipNode[K]) {
+	n := &l.head
+	for i := l.levels - 1; i >= 0; i-- {
+		for n.next[i] != nil && n.next[i].k < k {
+			n = n.next[i]
+		}
+		path[i] = n
+	}
+}
+
+func (l *skipList[K]) insert(k K) {
+	var path [maxLevel]*skipNode[K]
+	l.before(k, &path)
+	if n := path[0].next[0]; n != nil && n.k == k {
+		return
+	}
+	levels := 1
+	for levels < maxLevel && rand.Intn(4) == 0 {
+		levels++
+	}
+	for ; l.levels < levels; l.levels++ {
+		path[l.levels] = &l.head
+	}
+	n := &skipNode[K]{k: k, next: make([]*skipNode[K], levels)}
+	for i := range n.next {
+		n.next[i] = path[i].next[i]
+		path[i].next[i] = n
+	}
+}
+
+func (l *skipList[K]) remove(k K) {
+	var path [maxLevel]*skipNode[K]
+	l.before(k, &path)
+	n := path[0].next[0]
+	if n == nil || n.k != k {
+		return
+	}
+	for i := range n.next {
+		path[i].next[i] = n.next[i]
+	}
+	for l.levels > 1 && l.head.next[l.levels-1] == nil {
+		l.levels--
+	}
+}
+
+func (l *skipList[K]) reset() {
+	for i := range l.head.next {
+		l.head.next[i] = nil
+	}
+	l.levels = 1
+}
+
+// inRange returns the cache's items with a key in [lo, hi), in ascending key order if the cache
+// has an ordered index and in no particular order otherwise.
+func inRange[K Ordered, V any](c *Cache[K, V], lo, hi K) []*item[K, V] {
+	var items []*item[K, V]
+	if index, ok := c.index.(*skipList[K]); ok {
+		var path [maxLevel]*skipNode[K]
+		index.before(lo, &path)
+		for n := path[0].next[0]; n != nil && n.k < hi; n = n.next[0] {
+			item, _ := c.items.Get(n.k)
+			items = append(items, item)
+		}
+		return items
+	}
+	c.items.Range(func(k K, item *item[K, V]) bool {
+		if lo <= k && k < hi {
+			items = append(items, item)
+		}
+		return true
+	})
+	return items
+}
+
+// RangeBetween calls fn, in ascending key order, for each entry with a key in [lo, hi), until fn
+// returns false. Entries are not refreshed. fn is called without holding the cache's lock, on a
+// snapshot taken when RangeBetween was called.
+//
+// Without WithOrderedIndex, RangeBetween scans every entry, it is meant for occasional
+// invalidation and inspection.
+func RangeBetween[K Ordered, V any](c *Cache[K, V], lo, hi K, fn func(K, V) bool) {
+	type entry struct {
+		k K
+		v V
+	}
+	c.lock()
+	items := inRange(c, lo, hi)
+	snapshot := make([]entry, len(items))
+	for i, item := range items {
+		snapshot[i] = entry{item.k, item.v}
+	}
+	sorted := c.index != nil
+	c.mu.Unlock()
+	if !sorted {
+		sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].k < snapshot[j].k })
+	}
+	for _, e := range snapshot {
+		if !fn(e.k, e.v) {
+			return
+		}
+	}
+}
+
+// RemoveRange removes every entry with a key in [lo, hi) and returns how many were removed.
+// Without WithOrderedIndex it scans every entry, like RangeBetween.
+func RemoveRange[K Ordered, V any](c *Cache[K, V], lo, hi K) int {
+	c.lock()
+	defer c.mu.Unlock()
+	items := inRange(c, lo, hi)
+	for _, item := range items {
+		c.delete(item, Removed)
+	}
+	c.shrinkIfSparse(len(items))
+	return len(items)
+}
@@ -0,0 +1,71 @@
+package lru
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestRange(t *testing.T) {
+	c := New[int](5, time.Hour, func(s string) {})
+	for i, s := range []string{"a", "b", "c", "d", "e"} {
+		c.Put(i, s)
+	}
+	var got string
+	RangeBetween(c, 1, 4, func(k int, v string) bool {
+		got += v
+		return true
+	})
+	if got != "bcd" {
+		t.Fatalf("ranged over %q, want \"bcd\"", got)
+	}
+	if n := RemoveRange(c, 3, 10); n != 2 {
+		t.Fatalf("removed %d entries, want 2", n)
+	}
+	if _, ok := c.Get(3); ok {
+		t.Fatal("3 should not be in the cache anymore!")
+	}
+}
+
+func TestOrderedIndex(t *testing.T) {
+	c := New[int](100, time.Hour, func(i int) {}, WithOrderedIndex[int]())
+	for _, i := range rand.Perm(200) {
+		c.Put(i, i)
+	}
+	c.RemoveAndGet(150)
+	c.Remove(151)
+	var got []int
+	RangeBetween(c, 145, 155, func(k, v int) bool {
+		got = append(got, k)
+		return true
+	})
+	var want []int
+	for i := 145; i < 155; i++ {
+		if c.Contains(i) {
+			want = append(want, i)
+		}
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("ranged over %v, want %v", got, want)
+	}
+	before := c.Len()
+	if n := RemoveRange(c, 0, 1000); n != before || c.Len() != 0 {
+		t.Fatalf("removed %d of %d entries, %d left", n, before, c.Len())
+	}
+	c.Put(1, 1)
+	c.Purge()
+	c.Put(2, 2)
+	got = nil
+	RangeBetween(c, 0, 10, func(k, v int) bool {
+		got = append(got, k)
+		return true
+	})
+	if fmt.Sprint(got) != "[2]" {
+		t.Fatalf("ranged over %v after Purge, want [2]", got)
+	}
+	if _, err := TryNew[string](1, time.Hour, func(i int) {}, WithOrderedIndex[int]()); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("mismatched key type: got %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,95 @@
+// Package otel records the events of an lru.Cache as OpenTelemetry metrics.
+//
+// It is a separate module so that the lru package itself doesn't depend on OpenTelemetry.
+package otel
+
+import (
+	"context"
+
+	"go-lru"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var reasons = []lru.EvictReason{lru.Capacity, lru.Expired, lru.Removed, lru.Replaced}
+
+// Instrumentation is an lru.Instrumentation recording hits, misses, puts and evictions by reason
+// as OpenTelemetry counters. Every measurement has a "cache" attribute with the name the
+// instrumentation was created with, and evictions a "reason" attribute.
+type Instrumentation struct {
+	lookups   metric.Int64Counter
+	puts      metric.Int64Counter
+	evictions metric.Int64Counter
+
+	hit, miss, put metric.AddOption
+	evicted        map[lru.EvictReason]metric.AddOption
+}
+
+var _ lru.Instrumentation = (*Instrumentation)(nil)
+
+// New returns an Instrumentation creating its instruments with meter, for the cache called name.
+// Pass it to lru.WithInstrumentation.
+func New(meter metric.Meter, name string) (*Instrumentation, error) {
+	lookups, err := meter.Int64Counter("lru.lookups", metric.WithDescription("Cache lookups, by result."))
+	if err != nil {
+		return nil, err
+	}
+	puts, err := meter.Int64Counter("lru.puts", metric.WithDescription("Values stored in the cache."))
+	if err != nil {
+		return nil, err
+	}
+	evictions, err := meter.Int64Counter("lru.evictions", metric.WithDescription("Entries that left the cache, by reason."))
+	if err != nil {
+		return nil, err
+	}
+	cache := attribute.String("cache", name)
+	i := &Instrumentation{
+		lookups:   lookups,
+		puts:      puts,
+		evictions: evictions,
+		hit:       metric.WithAttributes(cache, attribute.String("result", "hit")),
+		miss:      metric.WithAttributes(cache, attribute.String("result", "miss")),
+		put:       metric.WithAttributes(cache),
+		evicted:   make(map[lru.EvictReason]metric.AddOption, len(reasons)),
+	}
+	for _, reason := range reasons {
+		i.evicted[reason] = metric.WithAttributes(cache, attribute.String("reason", reason.String()))
+	}
+	return i, nil
+}
+
+func (i *Instrumentation) Hit() {
+	i.lookups.Add(context.Background(), 1, i.hit)
+}
+
+func (i *Instrumentation) Miss() {
+	i.lookups.Add(context.Background(), 1, i.miss)
+}
+
+func (i *Instrumentation) Put() {
+	i.puts.Add(context.Background(), 1, i.put)
+}
+
+func (i *Instrumentation) Evicted(reason lru.EvictReason) {
+	i.evictions.Add(context.Background(), 1, i.evicted[reason])
+}
+
+// Lener is satisfied by *lru.Cache of any type.
+type Lener interface {
+	Len() int
+}
+
+// RegisterLen registers a gauge reporting the number of entries in c, for the cache called name.
+// Unregister the returned registration when the cache is discarded.
+func RegisterLen(meter metric.Meter, name string, c Lener) (metric.Registration, error) {
+	entries, err := meter.Int64ObservableGauge("lru.entries", metric.WithDescription("Entries in the cache."))
+	if err != nil {
+		return nil, err
+	}
+	attrs := metric.WithAttributes(attribute.String("cache", name))
+	return meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(entries, int64(c.Len()), attrs)
+		return nil
+	}, entries)
+}
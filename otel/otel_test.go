@@ -0,0 +1,72 @@
+package otel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-lru"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestInstrumentation(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+	inst, err := New(meter, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := lru.New[string](1, time.Hour, func(i int) {}, lru.WithInstrumentation(inst))
+	if _, err := RegisterLen(meter, "test", c); err != nil {
+		t.Fatal(err)
+	}
+	c.Put("a", 1)
+	c.Get("a")
+	c.Put("b", 2)
+	c.Get("a")
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]int64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					got[m.Name+label(dp.Attributes)] = dp.Value
+				}
+			case metricdata.Gauge[int64]:
+				for _, dp := range data.DataPoints {
+					got[m.Name+label(dp.Attributes)] = dp.Value
+				}
+			}
+		}
+	}
+	for name, want := range map[string]int64{
+		"lru.lookups/hit":        1,
+		"lru.lookups/miss":       1,
+		"lru.puts":               2,
+		"lru.evictions/capacity": 1,
+		"lru.entries":            1,
+	} {
+		if got[name] != want {
+			t.Errorf("%s = %d, want %d", name, got[name], want)
+		}
+	}
+}
+
+// label returns the value of the attribute other than "cache" in set, prefixed with a slash, if
+// there is one.
+func label(set attribute.Set) string {
+	for _, kv := range set.ToSlice() {
+		if kv.Key != "cache" {
+			return "/" + kv.Value.AsString()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+// Package prometheus exports the statistics of an lru.Cache as Prometheus metrics.
+//
+// It is a separate module so that the lru package itself doesn't depend on the Prometheus client.
+package prometheus
+
+import (
+	"strconv"
+	"time"
+
+	"go-lru"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Source is the part of an *lru.Cache the collector reads, satisfied by *lru.Cache of any type.
+type Source interface {
+	Stats() lru.Stats
+	AgeQuantiles(qs ...float64) []time.Duration
+}
+
+// AgeQuantiles are the quantiles of entry age the collector reports.
+var AgeQuantiles = []float64{0.5, 0.9, 0.99}
+
+var reasons = []lru.EvictReason{lru.Capacity, lru.Expired, lru.Removed, lru.Replaced}
+
+// Collector is a prometheus.Collector reporting a cache's hits, misses, hit ratio, length,
+// evictions by reason and entry age quantiles. Every metric has a "cache" label with the name the
+// collector was created with, so collectors for several caches can share a registry.
+type Collector struct {
+	src Source
+
+	hits, misses, puts *prom.Desc
+	hitRatio, entries  *prom.Desc
+	evictions          *prom.Desc
+	age                *prom.Desc
+}
+
+var _ prom.Collector = (*Collector)(nil)
+
+// NewCollector returns a Collector reading src, labeled with name.
+func NewCollector(src Source, name string) *Collector {
+	labels := prom.Labels{"cache": name}
+	desc := func(metric, help string, variable ...string) *prom.Desc {
+		return prom.NewDesc("lru_"+metric, help, variable, labels)
+	}
+	return &Collector{
+		src:       src,
+		hits:      desc("hits_total", "Lookups that found a live entry."),
+		misses:    desc("misses_total", "Lookups that found no live entry."),
+		puts:      desc("puts_total", "Values stored."),
+		hitRatio:  desc("hit_ratio", "Fraction of lookups that were hits since the stats were last reset."),
+		entries:   desc("entries", "Number of entries in the cache."),
+		evictions: desc("evictions_total", "Entries that left the cache, by reason.", "reason"),
+		age:       desc("entry_age_seconds", "Quantiles of the time since entries were put.", "quantile"),
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prom.Desc) {
+	for _, d := range []*prom.Desc{c.hits, c.misses, c.puts, c.hitRatio, c.entries, c.evictions, c.age} {
+		ch <- d
+	}
+}
+
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	s := c.src.Stats()
+	ch <- prom.MustNewConstMetric(c.hits, prom.CounterValue, float64(s.Hits))
+	ch <- prom.MustNewConstMetric(c.misses, prom.CounterValue, float64(s.Misses))
+	ch <- prom.MustNewConstMetric(c.puts, prom.CounterValue, float64(s.Puts))
+	ch <- prom.MustNewConstMetric(c.hitRatio, prom.GaugeValue, s.HitRatio())
+	ch <- prom.MustNewConstMetric(c.entries, prom.GaugeValue, float64(s.Len))
+	for _, reason := range reasons {
+		ch <- prom.MustNewConstMetric(c.evictions, prom.CounterValue, float64(s.ByReason(reason)), reason.String())
+	}
+	for i, age := range c.src.AgeQuantiles(AgeQuantiles...) {
+		q := strconv.FormatFloat(AgeQuantiles[i], 'g', -1, 64)
+		ch <- prom.MustNewConstMetric(c.age, prom.GaugeValue, age.Seconds(), q)
+	}
+}
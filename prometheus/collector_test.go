@@ -0,0 +1,56 @@
+package prometheus
+
+import (
+	"testing"
+	"time"
+
+	"go-lru"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCollector(t *testing.T) {
+	c := lru.New[string](1, time.Hour, func(i int) {})
+	c.Put("a", 1)
+	c.Get("a")
+	c.Get("b")
+	c.Put("b", 2)
+	reg := prom.NewRegistry()
+	reg.MustRegister(NewCollector(c, "test"))
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]float64)
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			name := f.GetName()
+			for _, l := range m.GetLabel() {
+				if l.GetName() != "cache" {
+					name += "/" + l.GetValue()
+				}
+			}
+			switch {
+			case m.Counter != nil:
+				values[name] = m.GetCounter().GetValue()
+			case m.Gauge != nil:
+				values[name] = m.GetGauge().GetValue()
+			}
+		}
+	}
+	for name, want := range map[string]float64{
+		"lru_hits_total":               1,
+		"lru_misses_total":             1,
+		"lru_hit_ratio":                0.5,
+		"lru_entries":                  1,
+		"lru_evictions_total/capacity": 1,
+		"lru_evictions_total/replaced": 0,
+	} {
+		if got, ok := values[name]; !ok || got != want {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+	if _, ok := values["lru_entry_age_seconds/0.99"]; !ok {
+		t.Error("missing entry age quantiles")
+	}
+}
@@ -0,0 +1,32 @@
+package lru
+
+// ReadOnly is a view of a Cache that exposes lookups only, so it can be shared with code that
+// must not add, replace or remove entries.
+type ReadOnly[K comparable, V any] struct {
+	c *Cache[K, V]
+}
+
+// AsReadOnly returns a read-only view of c.
+func (c *Cache[K, V]) AsReadOnly() ReadOnly[K, V] {
+	return ReadOnly[K, V]{c: c}
+}
+
+func (r ReadOnly[K, V]) Get(k K) (V, bool) {
+	return r.c.Get(k)
+}
+
+func (r ReadOnly[K, V]) Peek(k K) (V, bool) {
+	return r.c.Peek(k)
+}
+
+func (r ReadOnly[K, V]) Contains(k K) bool {
+	return r.c.Contains(k)
+}
+
+func (r ReadOnly[K, V]) Len() int {
+	return r.c.Len()
+}
+
+func (r ReadOnly[K, V]) Stats() Stats {
+	return r.c.Stats()
+}
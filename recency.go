@@ -0,0 +1,69 @@
+package lru
+
+// recency is an intrusive doubly linked list of items, most recently used first. The zero value
+// must be initialized with init before use.
+type recency[K any, V any] struct {
+	root item[K, V] // sentinel: root.next is the most recently used item, root.prev the least
+}
+
+func (l *recency[K, V]) init() {
+	l.root.next = &l.root
+	l.root.prev = &l.root
+}
+
+func (l *recency[K, V]) insertAfter(item, at *item[K, V]) {
+	item.prev = at
+	item.next = at.next
+	at.next.prev = item
+	at.next = item
+}
+
+func (l *recency[K, V]) pushFront(item *item[K, V]) {
+	l.insertAfter(item, &l.root)
+}
+
+func (l *recency[K, V]) remove(item *item[K, V]) {
+	item.prev.next = item.next
+	item.next.prev = item.prev
+	item.prev = nil
+	item.next = nil
+}
+
+func (l *recency[K, V]) moveToFront(item *item[K, V]) {
+	if l.root.next == item {
+		return
+	}
+	l.remove(item)
+	l.insertAfter(item, &l.root)
+}
+
+func (l *recency[K, V]) moveToBack(item *item[K, V]) {
+	if l.root.prev == item {
+		return
+	}
+	l.remove(item)
+	l.insertAfter(item, l.root.prev)
+}
+
+// front returns the most recently used item, or nil if the list is empty.
+func (l *recency[K, V]) front() *item[K, V] {
+	return l.or(l.root.next)
+}
+
+// back returns the least recently used item, or nil if the list is empty.
+func (l *recency[K, V]) back() *item[K, V] {
+	return l.or(l.root.prev)
+}
+
+// newer returns the item used next after item, or nil if item is the most recently used.
+func (l *recency[K, V]) newer(item *item[K, V]) *item[K, V] {
+	return l.or(item.prev)
+}
+
+// or returns item, or nil if it is the sentinel.
+func (l *recency[K, V]) or(item *item[K, V]) *item[K, V] {
+	if item == &l.root {
+		return nil
+	}
+	return item
+}
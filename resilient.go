@@ -0,0 +1,97 @@
+package lru
+
+import (
+	"errors"
+	"log"
+	"sync"
+)
+
+// Cacher is the subset of Cache's methods needed to use it as a plain key-value cache, so
+// wrappers such as Resilient can be stacked on top of a Cache or of each other.
+type Cacher[K comparable, V any] interface {
+	Put(k K, v V) error
+	Get(k K) (V, bool)
+	Remove(k K)
+	Len() int
+}
+
+var _ Cacher[string, int] = (*Cache[string, int])(nil)
+
+// ErrPanicked is returned by Resilient.Put when the wrapped cache panicked.
+var ErrPanicked = errors.New("lru: cache panicked")
+
+// Resilient wraps a Cacher and recovers from its panics: the operation that panicked degrades to
+// a miss (or ErrPanicked for Put), the panic is reported, and the wrapped cache is replaced with a
+// fresh one, since its internal state can no longer be trusted.
+type Resilient[K comparable, V any] struct {
+	mu      sync.RWMutex
+	c       Cacher[K, V]
+	fresh   func() Cacher[K, V]
+	onPanic func(any)
+}
+
+// NewResilient returns a Resilient serving from fresh() and calling fresh again to replace the
+// cache whenever it panics. To stop caching after the first panic, fresh can return a cache of
+// size 0. onPanic is called with every recovered value; if nil, panics are logged with the log
+// package.
+func NewResilient[K comparable, V any](fresh func() Cacher[K, V], onPanic func(any)) *Resilient[K, V] {
+	if onPanic == nil {
+		onPanic = func(v any) { log.Printf("lru: recovered from cache panic: %v", v) }
+	}
+	return &Resilient[K, V]{c: fresh(), fresh: fresh, onPanic: onPanic}
+}
+
+func (r *Resilient[K, V]) current() Cacher[K, V] {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.c
+}
+
+// recover must be deferred by every method calling into c. It reports whether c panicked.
+func (r *Resilient[K, V]) recover(c Cacher[K, V], panicked *bool) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	*panicked = true
+	r.onPanic(v)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.c == c {
+		// another goroutine may have replaced c already.
+		r.c = r.fresh()
+	}
+}
+
+func (r *Resilient[K, V]) Put(k K, v V) (err error) {
+	c := r.current()
+	var panicked bool
+	defer func() {
+		if panicked {
+			err = ErrPanicked
+		}
+	}()
+	defer r.recover(c, &panicked)
+	return c.Put(k, v)
+}
+
+func (r *Resilient[K, V]) Get(k K) (v V, ok bool) {
+	c := r.current()
+	var panicked bool
+	defer r.recover(c, &panicked)
+	return c.Get(k)
+}
+
+func (r *Resilient[K, V]) Remove(k K) {
+	c := r.current()
+	var panicked bool
+	defer r.recover(c, &panicked)
+	c.Remove(k)
+}
+
+func (r *Resilient[K, V]) Len() (n int) {
+	c := r.current()
+	var panicked bool
+	defer r.recover(c, &panicked)
+	return c.Len()
+}
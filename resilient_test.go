@@ -0,0 +1,42 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+type panicky struct {
+	*Cache[string, int]
+}
+
+func (p panicky) Get(k string) (int, bool) {
+	if k == "boom" {
+		panic("corrupted")
+	}
+	return p.Cache.Get(k)
+}
+
+func TestResilient(t *testing.T) {
+	fresh := 0
+	var recovered []any
+	r := NewResilient(func() Cacher[string, int] {
+		fresh++
+		return panicky{New[string](2, time.Hour, func(i int) {})}
+	}, func(v any) { recovered = append(recovered, v) })
+	r.Put("a", 1)
+	if v, ok := r.Get("a"); !ok || v != 1 {
+		t.Fatalf("'a' value %d is not 1", v)
+	}
+	if _, ok := r.Get("boom"); ok {
+		t.Fatal("a panicking Get should miss")
+	}
+	if len(recovered) != 1 || recovered[0] != "corrupted" {
+		t.Fatalf("recovered %v, want [corrupted]", recovered)
+	}
+	if fresh != 2 || r.Len() != 0 {
+		t.Fatalf("cache was not replaced: %d instances, len %d", fresh, r.Len())
+	}
+	if err := r.Put("a", 2); err != nil {
+		t.Fatal(err)
+	}
+}
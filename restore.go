@@ -0,0 +1,76 @@
+package lru
+
+import "time"
+
+// softRemoval is an entry removed by RemoveSoft, with the timer dropping it after the restore
+// window.
+type softRemoval[K comparable, V any] struct {
+	item  *item[K, V]
+	timer *time.Timer
+}
+
+// RemoveSoft removes k from the cache but keeps its entry for the window set by
+// WithRestoreWindow, during which Restore can bring it back. Lookups miss k as if it was removed.
+// onEvicted is called once the window has passed without a Restore. Without WithRestoreWindow,
+// RemoveSoft is like Remove. It reports whether k was in the cache.
+func (c *Cache[K, V]) RemoveSoft(k K) bool {
+	c.lock()
+	defer c.mu.Unlock()
+	item, exists := c.items.Get(k)
+	if !exists {
+		return false
+	}
+	if c.restoreWindow <= 0 {
+		c.delete(item, Removed)
+		return true
+	}
+	c.invalidateLoad(k)
+	c.unlink(item)
+	if old, exists := c.removed[k]; exists {
+		c.drop(old)
+	}
+	if c.removed == nil {
+		c.removed = make(map[K]*softRemoval[K, V])
+	}
+	r := &softRemoval[K, V]{item: item}
+	c.removed[k] = r
+	r.timer = time.AfterFunc(c.restoreWindow, func() {
+		c.lock()
+		defer c.mu.Unlock()
+		// k may have been restored and removed again since, with a timer of its own.
+		if c.removed[k] == r {
+			c.drop(r)
+		}
+	})
+	return true
+}
+
+// Restore puts back the entry for k removed by RemoveSoft, with its original expiration, ttl and
+// tag. It reports whether the entry was restored: it is not if the restore window has passed, if
+// k was put again since it was removed, or if the cache has no room for it, in which case it is
+// evicted right away like a new entry would be.
+func (c *Cache[K, V]) Restore(k K) bool {
+	c.lock()
+	defer c.mu.Unlock()
+	r, removed := c.removed[k]
+	if !removed {
+		return false
+	}
+	if _, exists := c.items.Get(k); exists {
+		return false
+	}
+	r.timer.Stop()
+	delete(c.removed, k)
+	c.invalidateLoad(k)
+	c.add(r.item)
+	restored, exists := c.items.Get(k)
+	return exists && restored == r.item
+}
+
+// drop finally removes the entry removed by RemoveSoft, calling onEvicted.
+func (c *Cache[K, V]) drop(r *softRemoval[K, V]) {
+	r.timer.Stop()
+	delete(c.removed, r.item.k)
+	c.record(r.item, Removed)
+	c.notify(r.item, r.item.v, Removed)
+}
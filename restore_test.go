@@ -0,0 +1,101 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRemoveSoft(t *testing.T) {
+	evicted := make(chan int, 1)
+	c := New[string](2, time.Hour, func(i int) { evicted <- i }, WithRestoreWindow(20*time.Millisecond))
+	c.PutWithTag("a", 1, "tag")
+	if !c.RemoveSoft("a") {
+		t.Fatal("'a' should have been removed")
+	}
+	if c.Contains("a") {
+		t.Fatal("soft removed 'a' should be hidden")
+	}
+	if !c.Restore("a") {
+		t.Fatal("'a' should have been restored")
+	}
+	if tag, _ := c.Tag("a"); tag != "tag" {
+		t.Fatalf("restored 'a' tag %v is not 'tag'", tag)
+	}
+	c.RemoveSoft("a")
+	select {
+	case v := <-evicted:
+		if v != 1 {
+			t.Fatalf("evicted %d, want 1", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("'a' was not evicted after the restore window")
+	}
+	if c.Restore("a") {
+		t.Fatal("'a' should not be restorable after the window")
+	}
+}
+
+func TestRestoreAfterPut(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {}, WithRestoreWindow(time.Hour))
+	c.Put("a", 1)
+	c.RemoveSoft("a")
+	c.Put("a", 2)
+	if c.Restore("a") {
+		t.Fatal("Restore should not replace a newer 'a'")
+	}
+	if v, _ := c.Get("a"); v != 2 {
+		t.Fatalf("'a' value %d is not 2", v)
+	}
+}
+
+func TestRemoveSoftTwice(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {}, WithRestoreWindow(100*time.Millisecond))
+	c.Put("a", 1)
+	c.RemoveSoft("a")
+	time.Sleep(50 * time.Millisecond)
+	c.Restore("a")
+	c.RemoveSoft("a")
+	time.Sleep(75 * time.Millisecond)
+	if !c.Restore("a") {
+		t.Fatal("the first removal's window should not cut the second one short")
+	}
+}
+
+func TestRestoreAfterPutAndRemove(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {}, WithRestoreWindow(time.Hour))
+	c.Put("a", 1)
+	c.RemoveSoft("a")
+	c.Put("a", 2)
+	c.Remove("a")
+	if c.Restore("a") {
+		t.Fatal("Restore should not bring back the value replaced by a Put")
+	}
+}
+
+func TestRestoreDuringLoad(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {}, WithRestoreWindow(time.Minute))
+	c.Put("a", 1)
+	c.RemoveSoft("a")
+	c.GetOrLoad("a", func(string) (int, error) {
+		c.Restore("a")
+		return 99, nil
+	})
+	if v, _ := c.Get("a"); v != 1 {
+		t.Fatalf("'a' value %d is not the restored 1", v)
+	}
+}
+
+func TestRestoreNoRoom(t *testing.T) {
+	evicted := 0
+	c := New[string](1, time.Hour, func(i int) { evicted++ }, WithRestoreWindow(time.Minute))
+	c.Put("a", 1)
+	c.RemoveSoft("a")
+	c.Put("b", 2)
+	c.Pin("b")
+	if c.Restore("a") || c.Contains("a") {
+		t.Fatal("'a' should not be restored into a cache full of pinned entries")
+	}
+	if evicted != 1 {
+		t.Fatalf("onEvicted called %d times, want once for 'a'", evicted)
+	}
+}
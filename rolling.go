@@ -0,0 +1,111 @@
+package lru
+
+import (
+	"sync"
+	"time"
+)
+
+// RollingCache keeps entries in a ring of time slices, each covering window. Puts go to the
+// current slice, Gets look through all of them, and every window the oldest slice is dropped
+// wholesale, so an entry lives between (slices-1)*window and slices*window after it was put.
+// Expiring a slice is O(1) regardless of how many entries it holds.
+type RollingCache[K comparable, V any] struct {
+	mu      sync.Mutex
+	window  time.Duration
+	slices  []map[K]V // slices[0] is the current slice
+	started time.Time // when slices[0] became current
+}
+
+// NewRolling returns a RollingCache of slices time slices each covering window.
+func NewRolling[K comparable, V any](slices int, window time.Duration) *RollingCache[K, V] {
+	if slices <= 0 || window <= 0 {
+		panic("RollingCache: slices and window must be positive")
+	}
+	r := &RollingCache[K, V]{
+		window:  window,
+		slices:  make([]map[K]V, slices),
+		started: time.Now(),
+	}
+	for i := range r.slices {
+		r.slices[i] = make(map[K]V)
+	}
+	return r
+}
+
+// rotate drops the slices that ended before now.
+func (r *RollingCache[K, V]) rotate(now time.Time) {
+	n := int(now.Sub(r.started) / r.window)
+	if n == 0 {
+		return
+	}
+	r.started = r.started.Add(time.Duration(n) * r.window)
+	if n > len(r.slices) {
+		n = len(r.slices)
+	}
+	copy(r.slices[n:], r.slices)
+	for i := 0; i < n; i++ {
+		r.slices[i] = make(map[K]V)
+	}
+}
+
+func (r *RollingCache[K, V]) Put(k K, v V) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rotate(time.Now())
+	for _, s := range r.slices[1:] {
+		delete(s, k)
+	}
+	r.slices[0][k] = v
+}
+
+func (r *RollingCache[K, V]) Get(k K) (V, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rotate(time.Now())
+	for _, s := range r.slices {
+		if v, exists := s[k]; exists {
+			return v, true
+		}
+	}
+	var v V
+	return v, false
+}
+
+// Update atomically replaces the value for k with update(old, exists) in the current slice, and
+// returns the new value.
+func (r *RollingCache[K, V]) Update(k K, update func(old V, exists bool) V) V {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rotate(time.Now())
+	var old V
+	exists := false
+	for _, s := range r.slices {
+		if old, exists = s[k]; exists {
+			delete(s, k)
+			break
+		}
+	}
+	v := update(old, exists)
+	r.slices[0][k] = v
+	return v
+}
+
+func (r *RollingCache[K, V]) Remove(k K) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.slices {
+		delete(s, k)
+	}
+}
+
+// Len returns the number of entries in all live slices.
+func (r *RollingCache[K, V]) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rotate(time.Now())
+	n := 0
+	for _, s := range r.slices {
+		n += len(s)
+	}
+	return n
+}
@@ -0,0 +1,37 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRollingCache(t *testing.T) {
+	const window = 20 * time.Millisecond
+	r := NewRolling[string, int](2, window)
+	r.Put("a", 1)
+	time.Sleep(window)
+	r.Put("b", 2)
+	if v, ok := r.Get("a"); !ok || v != 1 {
+		t.Fatalf("'a' value %d is not 1", v)
+	}
+	time.Sleep(window)
+	if _, ok := r.Get("a"); ok {
+		t.Fatal("'a' should have rolled out")
+	}
+	if l := r.Len(); l != 1 {
+		t.Fatalf("size %d is not 1", l)
+	}
+	r.Remove("b")
+	if _, ok := r.Get("b"); ok {
+		t.Fatal("'b' should not be in the cache anymore!")
+	}
+}
+
+func TestRollingUpdate(t *testing.T) {
+	r := NewRolling[string, int](2, time.Hour)
+	add := func(n int, _ bool) int { return n + 1 }
+	r.Update("a", add)
+	if v := r.Update("a", add); v != 2 {
+		t.Fatalf("'a' value %d is not 2", v)
+	}
+}
@@ -0,0 +1,48 @@
+package lru
+
+// RequestScope is an unsynchronized cache in front of a parent Cache, meant to live for a single
+// request. The first lookup of a key goes to the parent; its result, hit or miss, is remembered so
+// repeated lookups don't pay for the parent's lock and refresh again.
+//
+// A RequestScope must not be used by multiple goroutines at once.
+type RequestScope[K comparable, V any] struct {
+	parent *Cache[K, V]
+	local  map[K]scopeEntry[V]
+}
+
+type scopeEntry[V any] struct {
+	v  V
+	ok bool
+}
+
+// NewRequestScope returns an empty RequestScope over parent.
+func NewRequestScope[K comparable, V any](parent *Cache[K, V]) *RequestScope[K, V] {
+	return &RequestScope[K, V]{
+		parent: parent,
+		local:  make(map[K]scopeEntry[V]),
+	}
+}
+
+func (s *RequestScope[K, V]) Get(k K) (V, bool) {
+	if e, exists := s.local[k]; exists {
+		return e.v, e.ok
+	}
+	v, ok := s.parent.Get(k)
+	s.local[k] = scopeEntry[V]{v: v, ok: ok}
+	return v, ok
+}
+
+// Put stores v in both the scope and the parent.
+func (s *RequestScope[K, V]) Put(k K, v V) error {
+	if err := s.parent.Put(k, v); err != nil {
+		return err
+	}
+	s.local[k] = scopeEntry[V]{v: v, ok: true}
+	return nil
+}
+
+// Remove removes k from both the scope and the parent.
+func (s *RequestScope[K, V]) Remove(k K) {
+	s.parent.Remove(k)
+	s.local[k] = scopeEntry[V]{}
+}
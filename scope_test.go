@@ -0,0 +1,27 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequestScope(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {})
+	c.Put("a", 1)
+	s := NewRequestScope(c)
+	if v, ok := s.Get("a"); !ok || v != 1 {
+		t.Fatalf("'a' value %d is not 1", v)
+	}
+	c.Put("a", 2)
+	if v, _ := s.Get("a"); v != 1 {
+		t.Fatalf("scoped 'a' value %d is not 1", v)
+	}
+	s.Put("b", 3)
+	if v, _ := c.Get("b"); v != 3 {
+		t.Fatalf("parent 'b' value %d is not 3", v)
+	}
+	s.Remove("b")
+	if _, ok := s.Get("b"); ok {
+		t.Fatal("'b' should not be in the scope anymore!")
+	}
+}
@@ -0,0 +1,65 @@
+package lru
+
+// shadow is a keys-only LRU cache of another size, fed the same lookups and puts as the cache it
+// shadows, see WithShadow.
+type shadow[K comparable] struct {
+	size  int
+	keys  map[K]*item[K, struct{}]
+	order recency[K, struct{}]
+}
+
+func newShadow[K comparable](size int) *shadow[K] {
+	s := &shadow[K]{size: size}
+	s.reset()
+	return s
+}
+
+func (s *shadow[K]) reset() {
+	s.keys = make(map[K]*item[K, struct{}])
+	s.order.init()
+}
+
+// lookup reports whether k is in the shadow, making it the most recently used. A missing k is
+// added, as the cache would after loading it.
+func (s *shadow[K]) lookup(k K) bool {
+	if item, exists := s.keys[k]; exists {
+		s.order.moveToFront(item)
+		return true
+	}
+	s.put(k)
+	return false
+}
+
+// put adds k, or makes it the most recently used, evicting the least recently used key if the
+// shadow is full.
+func (s *shadow[K]) put(k K) {
+	if item, exists := s.keys[k]; exists {
+		s.order.moveToFront(item)
+		return
+	}
+	if len(s.keys) >= s.size {
+		s.remove(s.order.back().k)
+	}
+	item := &item[K, struct{}]{k: k}
+	s.keys[k] = item
+	s.order.pushFront(item)
+}
+
+func (s *shadow[K]) remove(k K) {
+	if item, exists := s.keys[k]; exists {
+		delete(s.keys, k)
+		s.order.remove(item)
+	}
+}
+
+// shadowLookup feeds a lookup of k to the cache's shadow, if it has one.
+func (c *Cache[K, V]) shadowLookup(k K) {
+	if c.shadow == nil {
+		return
+	}
+	if c.shadow.lookup(k) {
+		c.stats.shadowHits.Add(1)
+	} else {
+		c.stats.shadowMisses.Add(1)
+	}
+}
@@ -0,0 +1,23 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShadow(t *testing.T) {
+	c := New[string](1, time.Hour, func(i int) {}, WithShadow(2))
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Get("a")
+	c.Get("b")
+	s := c.Stats()
+	if s.Hits != 1 || s.Misses != 1 || s.ShadowHits != 2 || s.ShadowMisses != 0 {
+		t.Fatalf("stats %+v, want 1 hit and 1 miss, and 2 hits in the shadow", s)
+	}
+	c.Remove("a")
+	c.Get("a")
+	if s := c.Stats(); s.ShadowMisses != 1 || s.ShadowHitRatio() != 2.0/3 {
+		t.Fatalf("stats %+v, want the removed 'a' to miss in the shadow", s)
+	}
+}
@@ -0,0 +1,278 @@
+package lru
+
+import (
+	"encoding/json"
+	"expvar"
+	"sync/atomic"
+	"time"
+
+	"go-lru/keyedheap"
+)
+
+// StatsSchemaVersion is the "version" field of Stats encoded as JSON. It is incremented when a
+// field is renamed or its meaning changes; adding fields does not change it.
+const StatsSchemaVersion = 1
+
+// Stats is a snapshot of a Cache's counters.
+//
+// Encoded as JSON, Stats is an object with the fields below under their json names, durations in
+// nanoseconds, plus "version" (StatsSchemaVersion) and the derived "hit_ratio" (see HitRatio).
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Puts      uint64 `json:"puts"`
+	Evictions uint64 `json:"evictions"` // entries evicted to make room for new ones
+
+	Expirations  uint64 `json:"expirations"`  // entries removed because they expired
+	Removals     uint64 `json:"removals"`     // entries explicitly removed
+	Replacements uint64 `json:"replacements"` // values overwritten by a Put or Move
+
+	Overwrites uint64 `json:"overwrites"`  // Puts replacing a value that had not expired
+	DeadWrites uint64 `json:"dead_writes"` // values replaced or removed without ever being read
+
+	Len int `json:"len"` // number of entries when the snapshot was taken
+
+	LockContentions uint64        `json:"lock_contentions"` // lock acquisitions that had to wait
+	LockWait        time.Duration `json:"lock_wait_ns"`     // total time spent waiting for the lock
+
+	Sweeps    uint64        `json:"sweeps"`        // runs of RemoveExpired, including the janitor's
+	SweepTime time.Duration `json:"sweep_time_ns"` // total time sweeps held the lock
+
+	Loads    uint64        `json:"loads"`        // values loaded by GetOrLoad, successfully or not
+	LoadTime time.Duration `json:"load_time_ns"` // total time spent loading them
+
+	// lookups that would have hit or missed in the cache of another size set by WithShadow
+	ShadowHits   uint64 `json:"shadow_hits"`
+	ShadowMisses uint64 `json:"shadow_misses"`
+}
+
+// HitRatio returns the fraction of lookups that were hits, or 0 if there were none.
+func (s Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// ShadowHitRatio is like HitRatio, for the cache of another size set by WithShadow.
+func (s Stats) ShadowHitRatio() float64 {
+	if s.ShadowHits+s.ShadowMisses == 0 {
+		return 0
+	}
+	return float64(s.ShadowHits) / float64(s.ShadowHits+s.ShadowMisses)
+}
+
+// ByReason returns the number of entries that left the cache for reason.
+func (s Stats) ByReason(reason EvictReason) uint64 {
+	switch reason {
+	case Capacity:
+		return s.Evictions
+	case Expired:
+		return s.Expirations
+	case Removed:
+		return s.Removals
+	case Replaced:
+		return s.Replacements
+	default:
+		return 0
+	}
+}
+
+// MarshalJSON encodes s with its schema version and derived ratios.
+func (s Stats) MarshalJSON() ([]byte, error) {
+	type fields Stats // without the MarshalJSON method
+	return json.Marshal(struct {
+		Version int `json:"version"`
+		fields
+		HitRatio float64 `json:"hit_ratio"`
+	}{StatsSchemaVersion, fields(s), s.HitRatio()})
+}
+
+// Delta returns the change in each counter and in Len since prev, which must be an earlier
+// snapshot of the same cache taken without a ResetStats in between.
+func (s Stats) Delta(prev Stats) Stats {
+	return Stats{
+		Hits:      s.Hits - prev.Hits,
+		Misses:    s.Misses - prev.Misses,
+		Puts:      s.Puts - prev.Puts,
+		Evictions: s.Evictions - prev.Evictions,
+
+		Expirations:  s.Expirations - prev.Expirations,
+		Removals:     s.Removals - prev.Removals,
+		Replacements: s.Replacements - prev.Replacements,
+
+		Overwrites: s.Overwrites - prev.Overwrites,
+		DeadWrites: s.DeadWrites - prev.DeadWrites,
+
+		Len: s.Len - prev.Len,
+
+		LockContentions: s.LockContentions - prev.LockContentions,
+		LockWait:        s.LockWait - prev.LockWait,
+
+		Sweeps:    s.Sweeps - prev.Sweeps,
+		SweepTime: s.SweepTime - prev.SweepTime,
+
+		Loads:    s.Loads - prev.Loads,
+		LoadTime: s.LoadTime - prev.LoadTime,
+
+		ShadowHits:   s.ShadowHits - prev.ShadowHits,
+		ShadowMisses: s.ShadowMisses - prev.ShadowMisses,
+	}
+}
+
+type counters struct {
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	puts      atomic.Uint64
+	evictions atomic.Uint64
+
+	expirations  atomic.Uint64
+	removals     atomic.Uint64
+	replacements atomic.Uint64
+
+	overwrites atomic.Uint64
+	deadWrites atomic.Uint64
+
+	lockContentions atomic.Uint64
+	lockWait        atomic.Uint64 // nanoseconds
+
+	sweeps    atomic.Uint64
+	sweepTime atomic.Uint64 // nanoseconds
+
+	loads    atomic.Uint64
+	loadTime atomic.Uint64 // nanoseconds
+
+	shadowHits   atomic.Uint64
+	shadowMisses atomic.Uint64
+
+	inst Instrumentation // nil unless WithInstrumentation
+}
+
+func (c *counters) hit() {
+	c.hits.Add(1)
+	if c.inst != nil {
+		c.inst.Hit()
+	}
+}
+
+func (c *counters) miss() {
+	c.misses.Add(1)
+	if c.inst != nil {
+		c.inst.Miss()
+	}
+}
+
+func (c *counters) put() {
+	c.puts.Add(1)
+	if c.inst != nil {
+		c.inst.Put()
+	}
+}
+
+func (c *counters) snapshot() Stats {
+	return Stats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Puts:      c.puts.Load(),
+		Evictions: c.evictions.Load(),
+
+		Expirations:  c.expirations.Load(),
+		Removals:     c.removals.Load(),
+		Replacements: c.replacements.Load(),
+
+		Overwrites: c.overwrites.Load(),
+		DeadWrites: c.deadWrites.Load(),
+
+		LockContentions: c.lockContentions.Load(),
+		LockWait:        time.Duration(c.lockWait.Load()),
+
+		Sweeps:    c.sweeps.Load(),
+		SweepTime: time.Duration(c.sweepTime.Load()),
+
+		Loads:    c.loads.Load(),
+		LoadTime: time.Duration(c.loadTime.Load()),
+
+		ShadowHits:   c.shadowHits.Load(),
+		ShadowMisses: c.shadowMisses.Load(),
+	}
+}
+
+// count counts an entry leaving the cache for reason.
+func (c *counters) count(reason EvictReason) {
+	switch reason {
+	case Capacity:
+		c.evictions.Add(1)
+	case Expired:
+		c.expirations.Add(1)
+	case Removed:
+		c.removals.Add(1)
+	case Replaced:
+		c.replacements.Add(1)
+	}
+	if c.inst != nil {
+		c.inst.Evicted(reason)
+	}
+}
+
+// dropped counts a value replaced or leaving the cache as a dead write, unless it was read.
+func (c *counters) dropped(read bool) {
+	if !read {
+		c.deadWrites.Add(1)
+	}
+}
+
+func (c *counters) reset() {
+	c.hits.Store(0)
+	c.misses.Store(0)
+	c.puts.Store(0)
+	c.evictions.Store(0)
+	c.expirations.Store(0)
+	c.removals.Store(0)
+	c.replacements.Store(0)
+	c.overwrites.Store(0)
+	c.deadWrites.Store(0)
+	c.lockContentions.Store(0)
+	c.lockWait.Store(0)
+	c.sweeps.Store(0)
+	c.sweepTime.Store(0)
+	c.loads.Store(0)
+	c.loadTime.Store(0)
+	c.shadowHits.Store(0)
+	c.shadowMisses.Store(0)
+}
+
+// Stats returns a snapshot of the cache's counters and its current length.
+func (c *Cache[K, V]) Stats() Stats {
+	s := c.stats.snapshot()
+	s.Len = c.Len()
+	return s
+}
+
+// ResetStats sets all of the cache's counters to zero. It does not affect Len.
+func (c *Cache[K, V]) ResetStats() {
+	c.stats.reset()
+}
+
+// PublishExpvar publishes the cache's Stats, encoded as JSON, as the expvar variable name, so they
+// show up in /debug/vars. Like expvar.Publish, it panics if name is already in use.
+func (c *Cache[K, V]) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any { return c.Stats() }))
+}
+
+// DebugStats counts operations on a Cache's internal structures, to attribute CPU time to them
+// when investigating performance.
+type DebugStats struct {
+	Heap keyedheap.Counters // operations on the heap ordering items by expiration
+}
+
+// DebugStats returns the cache's internal operation counts. They are all zero unless the cache
+// was created with WithDebugStats.
+func (c *Cache[K, V]) DebugStats() DebugStats {
+	c.lock()
+	defer c.mu.Unlock()
+	var s DebugStats
+	if c.heapCounters != nil {
+		s.Heap = *c.heapCounters
+	}
+	return s
+}
@@ -0,0 +1,139 @@
+package lru
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	c := New[string](1, time.Hour, func(i int) {})
+	c.Put("a", 1)
+	c.Get("a")
+	prev := c.Stats()
+	c.Put("b", 2)
+	c.Get("a")
+	c.Get("b")
+	want := Stats{Hits: 1, Misses: 1, Puts: 1, Evictions: 1}
+	if d := c.Stats().Delta(prev); d != want {
+		t.Fatalf("delta %+v is not %+v", d, want)
+	}
+	c.ResetStats()
+	if s := c.Stats(); s != (Stats{Len: 1}) {
+		t.Fatalf("stats %+v not reset", s)
+	}
+}
+
+func TestSweepStats(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {})
+	c.RemoveExpired()
+	c.RemoveExpired()
+	if s := c.Stats(); s.Sweeps != 2 || s.SweepTime <= 0 {
+		t.Fatalf("stats %+v, want 2 sweeps taking some time", s)
+	}
+	c.ResetStats()
+	if s := c.Stats(); s.Sweeps != 0 || s.SweepTime != 0 {
+		t.Fatalf("sweep stats %+v not reset", s)
+	}
+}
+
+func TestStatsByReason(t *testing.T) {
+	c := New[string](1, time.Hour, func(i int) {})
+	c.Put("a", 1)
+	c.Put("a", 2)
+	c.Put("b", 3)
+	c.ExpireMany([]string{"b"})
+	c.Put("c", 4)
+	c.Remove("c")
+	s := c.Stats()
+	for _, reason := range []EvictReason{Capacity, Expired, Removed, Replaced} {
+		if n := s.ByReason(reason); n != 1 {
+			t.Errorf("%d entries left for reason %v, want 1", n, reason)
+		}
+	}
+}
+
+func TestWriteAmplification(t *testing.T) {
+	c := New[string](2, time.Hour, func(i int) {})
+	c.Put("a", 1)
+	c.Put("a", 2) // overwrites an unread value
+	c.Get("a")
+	c.Put("a", 3) // overwrites a read value
+	c.Put("b", 4)
+	c.Remove("b") // removes an unread value
+	s := c.Stats()
+	if s.Overwrites != 2 || s.DeadWrites != 2 {
+		t.Fatalf("%d overwrites and %d dead writes, want 2 and 2", s.Overwrites, s.DeadWrites)
+	}
+}
+
+func TestPublishExpvar(t *testing.T) {
+	c := New[string](1, time.Hour, func(i int) {})
+	// expvar names can't be reused, e.g. with go test -count=2.
+	name := fmt.Sprintf("lru_test_cache_%d", time.Now().UnixNano())
+	c.PublishExpvar(name)
+	c.Put("a", 1)
+	c.Get("a")
+	var s Stats
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Hits != 1 || s.Len != 1 {
+		t.Fatalf("published stats %+v, want 1 hit and length 1", s)
+	}
+}
+
+func TestLockContention(t *testing.T) {
+	c := New[string](1, time.Hour, func(i int) {})
+	c.mu.Lock()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.Put("a", 1)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	c.mu.Unlock()
+	wg.Wait()
+	s := c.Stats()
+	if s.LockContentions != 1 || s.LockWait <= 0 {
+		t.Fatalf("contention %d, wait %v; want 1 contention with non-zero wait", s.LockContentions, s.LockWait)
+	}
+}
+
+func TestDebugStats(t *testing.T) {
+	c := New[string](1, time.Hour, func(i int) {}, WithDebugStats())
+	c.Put("a", 1)
+	c.Get("a")
+	c.Put("b", 2)
+	s := c.DebugStats()
+	if s.Heap.Pushes != 2 || s.Heap.Fixes != 1 || s.Heap.Removes != 1 {
+		t.Fatalf("unexpected heap counters %+v", s.Heap)
+	}
+	c = New[string](1, time.Hour, func(i int) {})
+	c.Put("a", 1)
+	if s := c.DebugStats(); s != (DebugStats{}) {
+		t.Fatalf("debug stats %+v counted without WithDebugStats", s)
+	}
+}
+
+func TestStatsJSON(t *testing.T) {
+	b, err := json.Marshal(Stats{Hits: 3, Misses: 1, LockWait: time.Microsecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["version"] != float64(StatsSchemaVersion) || m["hits"] != 3.0 || m["hit_ratio"] != 0.75 || m["lock_wait_ns"] != 1000.0 {
+		t.Fatalf("unexpected encoding: %s", b)
+	}
+	var s Stats
+	if err := json.Unmarshal(b, &s); err != nil || s.Hits != 3 || s.LockWait != time.Microsecond {
+		t.Fatalf("round trip: %+v, %v", s, err)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build go1.23
+
+package lru
+
+import "unique"
+
+// WithCanonicalKeys makes the cache store a canonical copy of each key it adds, shared by all
+// caches using this option, so that many entries or caches with equal string keys hold one copy
+// of the key's memory. K must match the cache's key type.
+func WithCanonicalKeys[K comparable]() Option {
+	return func(o *options) {
+		o.canonical = func(k K) K {
+			return unique.Make(k).Value()
+		}
+	}
+}
@@ -0,0 +1,28 @@
+//go:build go1.23
+
+package lru
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestCanonicalKeys(t *testing.T) {
+	a := New[string](2, time.Hour, func(i int) {}, WithCanonicalKeys[string]())
+	b := New[string](2, time.Hour, func(i int) {}, WithCanonicalKeys[string]())
+	a.Put(strings.Repeat("k", 3), 1)
+	b.Put(strings.Repeat("k", 3), 2)
+	ka, kb := a.Keys()[0], b.Keys()[0]
+	if ka != "kkk" || unsafe.StringData(ka) != unsafe.StringData(kb) {
+		t.Fatalf("keys %q and %q do not share memory", ka, kb)
+	}
+	if v, ok := a.Get("kkk"); !ok || v != 1 {
+		t.Fatalf("Get(kkk) = %v, %v, want 1, true", v, ok)
+	}
+	if _, err := TryNew[string](1, time.Hour, func(i int) {}, WithCanonicalKeys[int]()); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("mismatched key type: got %v, want ErrInvalidConfig", err)
+	}
+}